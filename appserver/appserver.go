@@ -15,6 +15,10 @@ import (
 	"github.com/gorilla/mux"
 	errs "github.com/pkg/errors"
 	"github.com/redhat-developer/app-service/configuration"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 )
 
 var (
@@ -34,6 +38,10 @@ type AppServer struct {
 	config     *configuration.Registry
 	router     *mux.Router
 	httpServer *http.Server
+	kubeConfig *rest.Config
+	kinds      *kubeclient.KindRegistry
+	caches     *kubeclient.CacheRegistry
+	streams    *streamRegistry
 
 	logger      *log.Logger
 	routesSetup sync.Once
@@ -42,21 +50,31 @@ type AppServer struct {
 // New creates a new AppServer object with reasonable defaults.
 func New(configFilePath string) (*AppServer, error) {
 	srv := &AppServer{
-		router: mux.NewRouter(),
-		logger: log.New(os.Stderr, "", 0),
+		router:  mux.NewRouter(),
+		logger:  log.New(os.Stderr, "", 0),
+		streams: newStreamRegistry(),
 	}
 	config, err := configuration.New(configFilePath)
 	if err != nil {
 		return nil, errs.Wrapf(err, "failed to create a new configuration registry from file %q", configFilePath)
 	}
 	srv.config = config
+	srv.kubeConfig, err = newKubernetesConfig(config)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to create the kubernetes client config for mode %q", config.GetKubernetesClientMode())
+	}
+	srv.kinds = kubeclient.NewDefaultKindRegistry()
+	if err := registerOperatorBackedKinds(srv.kinds, config.GetTopologyOperatorBackedKinds()); err != nil {
+		return nil, err
+	}
+	srv.caches = kubeclient.NewCacheRegistry(srv.kinds, srv.config.GetCacheResyncPeriod(), srv.config.GetCacheSyncTimeout())
 	srv.httpServer = &http.Server{
 		Addr: srv.config.GetHTTPAddress(),
 		// Good practice to set timeouts to avoid Slowloris attacks.
 		WriteTimeout: srv.config.GetHTTPWriteTimeout(),
 		ReadTimeout:  srv.config.GetHTTPReadTimeout(),
 		IdleTimeout:  srv.config.GetHTTPIdleTimeout(),
-		Handler:      handlers.CombinedLoggingHandler(os.Stdout, srv.router),
+		Handler:      handlers.CombinedLoggingHandler(os.Stdout, redactAccessTokenHandler(srv.router)),
 	}
 	if srv.config.GetHTTPCompressResponses() {
 		srv.router.Use(handlers.CompressHandler)
@@ -79,6 +97,27 @@ func (srv *AppServer) HTTPServer() *http.Server {
 	return srv.httpServer
 }
 
+// Registers the custom resource kinds that are shown as operator-backed nodes.
+// Every kind is given as kind.version.group.
+func registerOperatorBackedKinds(kinds *kubeclient.KindRegistry, args []string) error {
+	for _, arg := range args {
+		gvk, _ := schema.ParseKindArg(arg)
+		if gvk == nil {
+			return errs.Errorf("invalid operator-backed kind %q, expected kind.version.group", arg)
+		}
+		if err := kinds.Register(kubeclient.NewDynamicKind(gvk.Kind, *gvk, true, "operator-backed")); err != nil {
+			return errs.Wrapf(err, "failed to register operator-backed kind %q", arg)
+		}
+	}
+	return nil
+}
+
+// Kinds returns the registry of the resource kinds that make up the topology.
+// Additional kinds must be registered before the server starts.
+func (srv *AppServer) Kinds() *kubeclient.KindRegistry {
+	return srv.kinds
+}
+
 // Router returns the app server's HTTP router
 func (srv *AppServer) Router() *mux.Router {
 	return srv.router
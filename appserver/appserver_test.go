@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/redhat-developer/app-service/kubeclient"
 	"github.com/redhat-developer/app-service/testutils"
 
 	uuid "github.com/satori/go.uuid"
@@ -77,3 +78,16 @@ func TestAppServer_GetRegisteredRoutes(t *testing.T) {
 		testutils.CompareWithGolden(t, testFile, routes, testutils.CompareOptions{})
 	})
 }
+
+func TestRegisterOperatorBackedKinds(t *testing.T) {
+	kinds := kubeclient.NewDefaultKindRegistry()
+	require.NoError(t, registerOperatorBackedKinds(kinds, []string{"EtcdCluster.v1beta2.etcd.database.coreos.com"}))
+	k, ok := kinds.Lookup("EtcdCluster")
+	require.True(t, ok)
+	require.True(t, k.Node)
+	require.Equal(t, "operator-backed", k.NodeType)
+	require.Equal(t, "etcd.database.coreos.com", k.Resource.Group)
+
+	require.Error(t, registerOperatorBackedKinds(kinds, []string{"EtcdCluster"}))
+	require.Error(t, registerOperatorBackedKinds(kinds, []string{"EtcdCluster.v1beta2.etcd.database.coreos.com"}))
+}
@@ -0,0 +1,160 @@
+package appserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/configuration"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	"k8s.io/client-go/rest"
+)
+
+// accessTokenParam is the name of the cookie and query parameter that can
+// carry the bearer token of the caller. Browsers cannot set the Authorization
+// header on web socket requests, so those need one of the alternatives.
+const accessTokenParam = "access_token"
+
+// errMissingToken is returned when a caller without a bearer token asks for a
+// client and anonymous access is off.
+var errMissingToken = errs.New("missing bearer token")
+
+// Extracts the bearer token of the caller from the Authorization header, the
+// access_token cookie or the access_token query parameter, in that order.
+func bearerToken(r *http.Request) string {
+	if token := authorizationToken(r); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(accessTokenParam); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	return r.URL.Query().Get(accessTokenParam)
+}
+
+// Wraps the handler of an access log so that the log does not reveal the
+// access_token query parameter. The handler is served with a copy of the
+// request that still carries the token.
+func redactAccessTokenHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served := r.WithContext(r.Context())
+		r.RequestURI = redactAccessToken(r.RequestURI)
+		h.ServeHTTP(w, served)
+	})
+}
+
+// Replaces the values of the access_token query parameters of the request
+// URI and keeps the rest of it as it is.
+func redactAccessToken(uri string) string {
+	i := strings.Index(uri, "?")
+	if i < 0 {
+		return uri
+	}
+	var b strings.Builder
+	b.WriteString(uri[:i+1])
+	query := uri[i+1:]
+	for query != "" {
+		param := query
+		separator := ""
+		if j := strings.IndexAny(query, "&;"); j >= 0 {
+			param, separator, query = query[:j], query[j:j+1], query[j+1:]
+		} else {
+			query = ""
+		}
+		key := param
+		if j := strings.Index(param, "="); j >= 0 {
+			key = param[:j]
+		}
+		if name, err := url.QueryUnescape(key); err == nil && name == accessTokenParam {
+			param = key + "=REDACTED"
+		}
+		b.WriteString(param)
+		b.WriteString(separator)
+	}
+	return b.String()
+}
+
+// Extracts the bearer token of the caller from the Authorization header only.
+// Browsers never set that header on their own, unlike cookies, which they
+// send along with requests of other sites.
+func authorizationToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
+}
+
+// Creates the REST config of the configured client mode that all clients are
+// derived from.
+func newKubernetesConfig(config *configuration.Registry) (*rest.Config, error) {
+	tls := kubeclient.TLSOptions{
+		CAFile:   config.GetKubernetesCAFile(),
+		CertFile: config.GetKubernetesCertFile(),
+		KeyFile:  config.GetKubernetesKeyFile(),
+		Insecure: config.IsKubernetesInsecure(),
+	}
+	switch mode := config.GetKubernetesClientMode(); mode {
+	case configuration.KubernetesClientModeToken:
+		return kubeclient.TokenConfig(config.GetKubernetesAPIURL(), "", tls), nil
+	case configuration.KubernetesClientModeInCluster:
+		return kubeclient.InClusterConfig(tls)
+	case configuration.KubernetesClientModeKubeconfig:
+		return kubeclient.KubeconfigConfig(config.GetKubernetesKubeconfig(), config.GetKubernetesContext(), tls)
+	default:
+		return nil, errs.Errorf("unknown kubernetes client mode %q", mode)
+	}
+}
+
+// Creates a client that acts on behalf of the caller. Callers without a bearer
+// token are rejected, unless anonymous access is turned on and the service
+// has credentials of its own, which they act with then.
+func (srv *AppServer) newKubeClient(r *http.Request) (*kubeclient.KubeClient, error) {
+	token := bearerToken(r)
+	if token != "" {
+		return kubeclient.NewKubeClientForConfig(kubeclient.WithBearerToken(srv.kubeConfig, token))
+	}
+	if srv.config.GetKubernetesClientMode() == configuration.KubernetesClientModeToken || !srv.config.IsKubernetesAnonymousAccess() {
+		return nil, errMissingToken
+	}
+	return kubeclient.NewKubeClientForConfig(srv.kubeConfig)
+}
+
+// Creates a client for the caller or answers the request with an error.
+func (srv *AppServer) requireKubeClient(w http.ResponseWriter, r *http.Request) *kubeclient.KubeClient {
+	k, err := srv.newKubeClient(r)
+	if err == errMissingToken {
+		unauthorized(w)
+		return nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	return k
+}
+
+// Creates a client that acts with the bearer token of the Authorization
+// header of the caller or answers the request with an error. Endpoints that
+// change objects use it, so that neither anonymous callers act with the
+// credentials of the service nor other sites with the cookie of the caller.
+func (srv *AppServer) requireCallerKubeClient(w http.ResponseWriter, r *http.Request) *kubeclient.KubeClient {
+	token := authorizationToken(r)
+	if token == "" {
+		unauthorized(w)
+		return nil
+	}
+	k, err := kubeclient.NewKubeClientForConfig(kubeclient.WithBearerToken(srv.kubeConfig, token))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	return k
+}
+
+// Answers the request of a caller without a bearer token.
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set(http.CanonicalHeaderKey("WWW-Authenticate"), "Bearer")
+	http.Error(w, errMissingToken.Error(), http.StatusUnauthorized)
+}
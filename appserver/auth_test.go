@@ -0,0 +1,222 @@
+package appserver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/handlers"
+	"github.com/redhat-developer/app-service/configuration"
+	"github.com/redhat-developer/app-service/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBearerToken(t *testing.T) {
+	t.Run("authorization header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/topology?access_token=query", nil)
+		r.Header.Set("Authorization", "Bearer header")
+		r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "cookie"})
+		require.Equal(t, "header", bearerToken(r))
+	})
+	t.Run("lower case scheme", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/topology", nil)
+		r.Header.Set("Authorization", "bearer header")
+		require.Equal(t, "header", bearerToken(r))
+	})
+	t.Run("cookie", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/topology?access_token=query", nil)
+		r.Header.Set("Authorization", "Basic Zm9vOmJhcg==")
+		r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "cookie"})
+		require.Equal(t, "cookie", bearerToken(r))
+	})
+	t.Run("query parameter", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/topology?access_token=query", nil)
+		require.Equal(t, "query", bearerToken(r))
+	})
+	t.Run("web socket protocol is ignored", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/topology", nil)
+		r.Header.Set("Sec-Websocket-Protocol", "https, api.example.com, 6443, secret")
+		require.Equal(t, "", bearerToken(r))
+	})
+}
+
+func TestRedactAccessToken(t *testing.T) {
+	tests := map[string]string{
+		"/topology":                                   "/topology",
+		"/topology?namespace=myproject":               "/topology?namespace=myproject",
+		"/topology?access_token=secret":               "/topology?access_token=REDACTED",
+		"/topology?namespace=a&access_token=secret&b": "/topology?namespace=a&access_token=REDACTED&b",
+		"/topology?access%5Ftoken=secret;namespace=a": "/topology?access%5Ftoken=REDACTED;namespace=a",
+		"/topology?access_token":                      "/topology?access_token=REDACTED",
+	}
+	for uri, expected := range tests {
+		require.Equal(t, expected, redactAccessToken(uri), uri)
+	}
+}
+
+func TestRedactAccessTokenHandler(t *testing.T) {
+	var log bytes.Buffer
+	var token string
+	h := handlers.CombinedLoggingHandler(&log, redactAccessTokenHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = bearerToken(r)
+		require.Equal(t, "/topology?access_token=secret", r.RequestURI)
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/topology?access_token=secret", nil))
+	require.Equal(t, "secret", token)
+	require.NotContains(t, log.String(), "secret")
+	require.Contains(t, log.String(), "/topology?access_token=REDACTED")
+}
+
+func TestAppServer_Unauthorized(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	for _, path := range []string{"/topology?namespace=myproject", "/topology/snapshot?namespace=myproject"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusUnauthorized, rr.Code)
+			require.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestAppServer_NewKubeClient(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_CLIENT_MODE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("unknown client mode", func(t *testing.T) {
+		os.Setenv(key, "magic")
+		srv, err := New("")
+		require.Error(t, err)
+		require.Nil(t, srv)
+	})
+
+	t.Run("token mode requires a token", func(t *testing.T) {
+		os.Setenv(key, configuration.KubernetesClientModeToken)
+		srv, err := New("")
+		require.NoError(t, err)
+
+		_, err = srv.newKubeClient(httptest.NewRequest(http.MethodGet, "/topology", nil))
+		require.Equal(t, errMissingToken, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/topology", nil)
+		r.Header.Set("Authorization", "Bearer token")
+		k, err := srv.newKubeClient(r)
+		require.NoError(t, err)
+		require.NotNil(t, k)
+	})
+
+	t.Run("kubeconfig mode requires a token", func(t *testing.T) {
+		srv, restore := newKubeconfigAppServer(t)
+		defer restore()
+
+		_, err := srv.newKubeClient(httptest.NewRequest(http.MethodGet, "/topology", nil))
+		require.Equal(t, errMissingToken, err)
+	})
+
+	t.Run("anonymous access", func(t *testing.T) {
+		anonymousKey := configuration.EnvPrefix + "_" + "KUBERNETES_ANONYMOUS_ACCESS"
+		resetFunc := testutils.UnsetEnvVarAndRestore(anonymousKey)
+		defer resetFunc()
+		os.Setenv(anonymousKey, "true")
+		srv, restore := newKubeconfigAppServer(t)
+		defer restore()
+
+		// Callers without a token act with the credentials of the service.
+		anonymous, err := srv.newKubeClient(httptest.NewRequest(http.MethodGet, "/topology", nil))
+		require.NoError(t, err)
+		r := httptest.NewRequest(http.MethodGet, "/topology", nil)
+		r.Header.Set("Authorization", "Bearer token")
+		k, err := srv.newKubeClient(r)
+		require.NoError(t, err)
+		require.NotEqual(t, k.Identity(), anonymous.Identity())
+	})
+}
+
+func TestAppServer_RequireCallerKubeClient(t *testing.T) {
+	anonymousKey := configuration.EnvPrefix + "_" + "KUBERNETES_ANONYMOUS_ACCESS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(anonymousKey)
+	defer resetFunc()
+	os.Setenv(anonymousKey, "true")
+	srv, restore := newKubeconfigAppServer(t)
+	defer restore()
+
+	t.Run("authorization header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/topology/edges", nil)
+		r.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		require.NotNil(t, srv.requireCallerKubeClient(rr, r))
+	})
+
+	// Neither the credentials of the service nor a token that browsers send
+	// along with requests of other sites are used.
+	for name, r := range map[string]*http.Request{
+		"missing token":   httptest.NewRequest(http.MethodPost, "/topology/edges", nil),
+		"query parameter": httptest.NewRequest(http.MethodPost, "/topology/edges?access_token=query", nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			require.Nil(t, srv.requireCallerKubeClient(rr, r))
+			require.Equal(t, http.StatusUnauthorized, rr.Code)
+			require.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+		})
+	}
+	t.Run("cookie", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/topology/edges", nil)
+		r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "cookie"})
+		rr := httptest.NewRecorder()
+		require.Nil(t, srv.requireCallerKubeClient(rr, r))
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+// Creates a server in the kubeconfig client mode with the credentials of a
+// service account. The returned function restores the environment.
+func newKubeconfigAppServer(t *testing.T) (*AppServer, func()) {
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+users:
+- name: service
+  user:
+    token: service-token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: service
+current-context: dev
+`
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "kubeconfig-")
+	require.NoError(t, err)
+	_, err = tmpFile.Write([]byte(kubeconfig))
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	modeKey := configuration.EnvPrefix + "_" + "KUBERNETES_CLIENT_MODE"
+	kubeconfigKey := configuration.EnvPrefix + "_" + "KUBERNETES_KUBECONFIG"
+	restoreMode := testutils.UnsetEnvVarAndRestore(modeKey)
+	restoreKubeconfig := testutils.UnsetEnvVarAndRestore(kubeconfigKey)
+	restore := func() {
+		restoreMode()
+		restoreKubeconfig()
+		os.Remove(tmpFile.Name())
+	}
+	os.Setenv(modeKey, configuration.KubernetesClientModeKubeconfig)
+	os.Setenv(kubeconfigKey, tmpFile.Name())
+	srv, err := New("")
+	if err != nil {
+		restore()
+		require.NoError(t, err)
+	}
+	return srv, restore
+}
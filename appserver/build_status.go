@@ -0,0 +1,100 @@
+package appserver
+
+import (
+	"strconv"
+
+	buildv1 "github.com/openshift/api/build/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// buildNumberAnnotation is the annotation of a build that holds its
+	// number within its build config.
+	buildNumberAnnotation = "openshift.io/build.number"
+	// buildConfigLabel is the label of a build that names its build config.
+	buildConfigLabel = "openshift.io/build-config.name"
+)
+
+// Gets the status of the latest build of the node, or nil if the node is not
+// built by any cached build config or has not been built yet.
+func getBuildStatus(c *kubeclient.Cache, node runtime.Object, references objectReferences) *topology.BuildStatus {
+	bc := getNodeBuildConfig(c, node, references)
+	if bc == nil {
+		return nil
+	}
+	build, number := getLatestBuild(c, bc)
+	if build == nil {
+		return nil
+	}
+	status := &topology.BuildStatus{
+		Name:        build.Name,
+		BuildConfig: bc.Name,
+		Number:      number,
+		Phase:       string(build.Status.Phase),
+	}
+	if build.Status.StartTimestamp != nil {
+		startTime := build.Status.StartTimestamp.Time
+		status.StartTime = &startTime
+	}
+	if build.Status.CompletionTimestamp != nil {
+		completionTime := build.Status.CompletionTimestamp.Time
+		status.CompletionTime = &completionTime
+	}
+	return status
+}
+
+// Gets the build config of the node. A build config that shares the
+// app.kubernetes.io/name label of the node is preferred over one whose output
+// is an image of the node. The build config is added to the references.
+func getNodeBuildConfig(c *kubeclient.Cache, node runtime.Object, references objectReferences) *buildv1.BuildConfig {
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return nil
+	}
+	if name := o.GetLabels()["app.kubernetes.io/name"]; name != "" {
+		for _, obj := range c.ByIndex(kubeclient.AppNameIndex, kubeclient.AppNameKey(o.GetNamespace(), name)) {
+			if bc, ok := obj.(*buildv1.BuildConfig); ok {
+				references.add(bc.Namespace, "BuildConfig", bc.Name)
+				return bc
+			}
+		}
+	}
+	for _, image := range getNodeImages(c, node) {
+		if bc := findBuildConfig(c, o.GetNamespace(), image, references); bc != nil {
+			return bc
+		}
+	}
+	return nil
+}
+
+// Gets the cached build of the build config with the highest number.
+func getLatestBuild(c *kubeclient.Cache, bc *buildv1.BuildConfig) (*buildv1.Build, int) {
+	var latest *buildv1.Build
+	latestNumber := -1
+	for _, obj := range c.ListKindInNamespace("Build", bc.Namespace) {
+		build, ok := obj.(*buildv1.Build)
+		if !ok || !isBuildOf(build, bc) {
+			continue
+		}
+		number, err := strconv.Atoi(build.Annotations[buildNumberAnnotation])
+		if err != nil {
+			number = 0
+		}
+		if number > latestNumber {
+			latest, latestNumber = build, number
+		}
+	}
+	return latest, latestNumber
+}
+
+// Checks whether the build was started from the build config.
+func isBuildOf(build *buildv1.Build, bc *buildv1.BuildConfig) bool {
+	if build.Status.Config != nil {
+		return build.Status.Config.Name == bc.Name
+	}
+	return build.Labels[buildConfigLabel] == bc.Name
+}
@@ -0,0 +1,75 @@
+package appserver
+
+import (
+	"testing"
+	"time"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppServer_BuildStatus(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	bc := &buildv1.BuildConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	started := metav1.NewTime(time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC))
+	completed := metav1.NewTime(started.Add(time.Minute))
+	build := func(name string, number string, phase buildv1.BuildPhase) *buildv1.Build {
+		return &buildv1.Build{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "myproject",
+				Annotations: map[string]string{"openshift.io/build.number": number},
+			},
+			Status: buildv1.BuildStatus{
+				Phase:          phase,
+				Config:         &corev1.ObjectReference{Kind: "BuildConfig", Name: "nodejs"},
+				StartTimestamp: &started,
+			},
+		}
+	}
+	failed := build("nodejs-10", "10", buildv1.BuildPhaseFailed)
+	failed.Status.CompletionTimestamp = &completed
+	other := build("other-11", "11", buildv1.BuildPhaseRunning)
+	other.Status.Config.Name = "other"
+	k := test.FakeKubeClient(dc, bc,
+		build("nodejs-2", "2", buildv1.BuildPhaseComplete),
+		failed,
+		other,
+	)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+
+	status := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Data.Build
+	require.NotNil(t, status)
+	require.Equal(t, "nodejs-10", status.Name)
+	require.Equal(t, "nodejs", status.BuildConfig)
+	require.Equal(t, 10, status.Number)
+	require.Equal(t, "Failed", status.Phase)
+	require.True(t, started.Time.Equal(*status.StartTime))
+	require.True(t, completed.Time.Equal(*status.CompletionTime))
+}
+
+func TestAppServer_BuildStatusWithoutBuilds(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), test.FakeKubeClient(dc), defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+	require.Nil(t, snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Data.Build)
+}
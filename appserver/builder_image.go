@@ -0,0 +1,155 @@
+package appserver
+
+import (
+	"strings"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// runtimeLabel is the label of a node that names its runtime explicitly.
+const runtimeLabel = "app.openshift.io/runtime"
+
+// Gets the builder image of the node, which tells the runtime it is built on.
+// In order of precedence it is the runtime label, the builder of the build
+// config that produces an image of the node, or the image the node runs.
+func getBuilderImage(c *kubeclient.Cache, node runtime.Object, references objectReferences) string {
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return ""
+	}
+	if name := o.GetLabels()[runtimeLabel]; name != "" {
+		return name
+	}
+
+	images := getNodeImages(c, node)
+	for _, image := range images {
+		if bc := findBuildConfig(c, o.GetNamespace(), image, references); bc != nil {
+			if from := getBuilderOf(bc); from != nil {
+				return imageName(from.Name)
+			}
+		}
+	}
+	if len(images) > 0 {
+		return imageName(images[0].Name)
+	}
+	return ""
+}
+
+// Gets the images of the node: the image stream tags its triggers follow and
+// the images of the containers of its pods.
+func getNodeImages(c *kubeclient.Cache, node runtime.Object) []corev1.ObjectReference {
+	var images []corev1.ObjectReference
+	if dc, ok := node.(*deploymentconfigv1.DeploymentConfig); ok {
+		for _, trigger := range dc.Spec.Triggers {
+			if trigger.Type == deploymentconfigv1.DeploymentTriggerOnImageChange && trigger.ImageChangeParams != nil {
+				images = append(images, trigger.ImageChangeParams.From)
+			}
+		}
+	}
+	kind, ok := c.Kinds().KindOf(node)
+	if !ok || kind.PodTemplate == nil {
+		return images
+	}
+	if template := kind.PodTemplate(node); template != nil {
+		for _, container := range template.Spec.Containers {
+			if container.Image != "" {
+				images = append(images, corev1.ObjectReference{Kind: "DockerImage", Name: container.Image})
+			}
+		}
+	}
+	return images
+}
+
+// Finds the cached build config of the namespace whose output is the image.
+// The build config and the image streams looked up on the way are added to
+// the references.
+func findBuildConfig(c *kubeclient.Cache, namespace string, image corev1.ObjectReference, references objectReferences) *buildv1.BuildConfig {
+	for _, obj := range c.ListKindInNamespace("BuildConfig", namespace) {
+		bc, ok := obj.(*buildv1.BuildConfig)
+		if !ok || bc.Spec.Output.To == nil {
+			continue
+		}
+		if sameImage(c, bc.Namespace, *bc.Spec.Output.To, namespace, image, references) {
+			references.add(bc.Namespace, "BuildConfig", bc.Name)
+			return bc
+		}
+	}
+	return nil
+}
+
+// Gets the image that the build config builds on.
+func getBuilderOf(bc *buildv1.BuildConfig) *corev1.ObjectReference {
+	strategy := bc.Spec.Strategy
+	switch {
+	case strategy.SourceStrategy != nil:
+		return &strategy.SourceStrategy.From
+	case strategy.DockerStrategy != nil:
+		return strategy.DockerStrategy.From
+	case strategy.CustomStrategy != nil:
+		return &strategy.CustomStrategy.From
+	}
+	return nil
+}
+
+// Checks whether the output of a build and the image of a node are the same.
+// A container image is matched against the repository of the image stream
+// that a build pushes to, which is added to the references whether it is
+// cached or not.
+func sameImage(c *kubeclient.Cache, outputNamespace string, output corev1.ObjectReference, namespace string, image corev1.ObjectReference, references objectReferences) bool {
+	if output.Namespace != "" {
+		outputNamespace = output.Namespace
+	}
+	if image.Namespace != "" {
+		namespace = image.Namespace
+	}
+	switch {
+	case output.Kind == image.Kind:
+		return outputNamespace == namespace && output.Name == image.Name
+	case output.Kind == "ImageStreamTag" && image.Kind == "DockerImage":
+		references.add(outputNamespace, "ImageStream", imageStreamName(output.Name))
+		stream, ok := c.Get("ImageStream", outputNamespace, imageStreamName(output.Name))
+		if !ok {
+			return false
+		}
+		repository := imageRepository(image.Name)
+		status := stream.(*imagev1.ImageStream).Status
+		return repository != "" && (repository == status.DockerImageRepository || repository == status.PublicDockerImageRepository)
+	}
+	return false
+}
+
+// Gets the name of the image stream of an image stream tag such as
+// "nodejs:10".
+func imageStreamName(tag string) string {
+	if i := strings.LastIndex(tag, ":"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// Gets the repository of a container image, i.e. the image without its tag or
+// digest.
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	// A colon after the last slash starts the tag rather than a port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// Gets the short name of an image or image stream tag, e.g. "nodejs" for
+// "registry.example.com/openshift/nodejs:10".
+func imageName(image string) string {
+	repository := imageRepository(image)
+	return repository[strings.LastIndex(repository, "/")+1:]
+}
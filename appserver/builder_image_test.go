@@ -0,0 +1,120 @@
+package appserver
+
+import (
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetBuilderImage(t *testing.T) {
+	template := func(image string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+		}
+	}
+	buildConfig := func(output corev1.ObjectReference) *buildv1.BuildConfig {
+		return &buildv1.BuildConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "nodejs-ex", Namespace: "myproject"},
+			Spec: buildv1.BuildConfigSpec{
+				CommonSpec: buildv1.CommonSpec{
+					Strategy: buildv1.BuildStrategy{
+						SourceStrategy: &buildv1.SourceBuildStrategy{
+							From: corev1.ObjectReference{Kind: "ImageStreamTag", Namespace: "openshift", Name: "nodejs:10"},
+						},
+					},
+					Output: buildv1.BuildOutput{To: &output},
+				},
+			},
+		}
+	}
+	imageStream := &imagev1.ImageStream{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs-ex", Namespace: "myproject"},
+		Status:     imagev1.ImageStreamStatus{DockerImageRepository: "172.30.1.1:5000/myproject/nodejs-ex"},
+	}
+	dcTemplate := template("172.30.1.1:5000/myproject/nodejs-ex@sha256:0123")
+
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		expected string
+	}{
+		{
+			name: "runtime label",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "myproject", Labels: map[string]string{"app.openshift.io/runtime": "quarkus"}},
+				Spec:       appsv1.DeploymentSpec{Template: template("quay.io/example/app:1.0")},
+			}},
+			expected: "quarkus",
+		},
+		{
+			name: "image stream tag of a trigger",
+			objects: []runtime.Object{
+				&deploymentconfigv1.DeploymentConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "myproject"},
+					Spec: deploymentconfigv1.DeploymentConfigSpec{
+						Triggers: []deploymentconfigv1.DeploymentTriggerPolicy{{
+							Type: deploymentconfigv1.DeploymentTriggerOnImageChange,
+							ImageChangeParams: &deploymentconfigv1.DeploymentTriggerImageChangeParams{
+								From: corev1.ObjectReference{Kind: "ImageStreamTag", Name: "nodejs-ex:latest"},
+							},
+						}},
+						Template: &dcTemplate,
+					},
+				},
+				buildConfig(corev1.ObjectReference{Kind: "ImageStreamTag", Name: "nodejs-ex:latest"}),
+			},
+			expected: "nodejs",
+		},
+		{
+			name: "image stream repository of a container",
+			objects: []runtime.Object{
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "myproject"},
+					Spec:       appsv1.DeploymentSpec{Template: template("172.30.1.1:5000/myproject/nodejs-ex:latest")},
+				},
+				buildConfig(corev1.ObjectReference{Kind: "ImageStreamTag", Name: "nodejs-ex:latest"}),
+				imageStream,
+			},
+			expected: "nodejs",
+		},
+		{
+			name: "container image",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "myproject"},
+				Spec:       appsv1.DeploymentSpec{Template: template("docker.io/library/nginx:1.15")},
+			}},
+			expected: "nginx",
+		},
+		{
+			name: "no image",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "myproject"},
+			}},
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := kubeclient.ListCache(test.FakeKubeClient(tt.objects...), kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, getBuilderImage(c, tt.objects[0], nil))
+		})
+	}
+}
+
+func TestImageName(t *testing.T) {
+	require.Equal(t, "nodejs", imageName("nodejs:10"))
+	require.Equal(t, "nginx", imageName("docker.io/library/nginx:1.15"))
+	require.Equal(t, "app", imageName("registry.example.com:5000/team/app"))
+	require.Equal(t, "app", imageName("registry.example.com:5000/team/app@sha256:0123"))
+}
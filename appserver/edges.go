@@ -0,0 +1,258 @@
+package appserver
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// connectsToAnnotation is the annotation of a node that lists the names of
+// the nodes it connects to.
+const connectsToAnnotation = "app.openshift.io/connects-to"
+
+// Types of the edges of the topology.
+const (
+	// edgeTypeConnectsTo edges are declared by the connects-to annotation.
+	edgeTypeConnectsTo = "connects-to"
+	// edgeTypeSelects edges lead from a node to the other nodes whose pods a
+	// service of the node selects.
+	edgeTypeSelects = "selects"
+	// edgeTypeServiceBinding edges lead from a node to the nodes behind the
+	// services that it refers to in its environment.
+	edgeTypeServiceBinding = "service-binding"
+	// edgeTypeOwnedBy edges lead from a node to the node that owns it.
+	edgeTypeOwnedBy = "owned-by"
+)
+
+// Suffixes of the environment variables that Kubernetes sets for the host and
+// port of a service, e.g. DATABASE_SERVICE_HOST.
+var serviceVariableSuffixes = []string{"_SERVICE_HOST", "_SERVICE_PORT"}
+
+// Get the edges from the nodes to the other nodes whose pods the services of
+// the nodes select, e.g. from a node to the nodes behind a service that it
+// exposes. Services are keyed like nodes since they are namespaced the same
+// way, and services that only select the pods of their own node make no
+// edges.
+func getServiceEdges(snapshot topology.Snapshot) []topology.Edge {
+	nodesByService := make(map[string][]string)
+	for _, name := range sortedNames(snapshot) {
+		entry := snapshot[name]
+		for _, r := range entry.Data.Resources {
+			if r.Kind == "Service" {
+				key := topology.NodeKey(entry.Meta.Namespace, "Service", r.Name)
+				nodesByService[key] = append(nodesByService[key], entry.Meta.ID)
+			}
+		}
+	}
+
+	var edges []topology.Edge
+	for _, name := range sortedNames(snapshot) {
+		target := snapshot[name].Meta
+		for _, service := range snapshot[name].Services {
+			for _, source := range nodesByService[topology.NodeKey(target.Namespace, "Service", service)] {
+				if source == target.ID {
+					continue
+				}
+				edges = append(edges, newEdge(source, target.ID, edgeTypeSelects))
+			}
+		}
+	}
+	return edges
+}
+
+// Get the edges from the nodes to the nodes behind the services that they
+// refer to. Services are keyed like nodes since they are namespaced the same
+// way.
+func getServiceBindingEdges(snapshot topology.Snapshot) []topology.Edge {
+	nodesByService := make(map[string][]topology.NodeMeta)
+	for _, name := range sortedNames(snapshot) {
+		entry := snapshot[name]
+		for _, service := range entry.Services {
+			key := topology.NodeKey(entry.Meta.Namespace, "Service", service)
+			nodesByService[key] = append(nodesByService[key], entry.Meta)
+		}
+	}
+
+	var edges []topology.Edge
+	for _, name := range sortedNames(snapshot) {
+		source := snapshot[name].Meta
+		for _, service := range snapshot[name].ServiceReferences {
+			for _, target := range nodesByService[topology.NodeKey(source.Namespace, "Service", service)] {
+				if target.ID == source.ID {
+					continue
+				}
+				edges = append(edges, newEdge(source.ID, target.ID, edgeTypeServiceBinding))
+			}
+		}
+	}
+	return edges
+}
+
+// Get the edges from the nodes to the nodes that own them.
+func getOwnerEdges(snapshot topology.Snapshot) []topology.Edge {
+	nodesByUID := make(map[types.UID]string, len(snapshot))
+	for _, entry := range snapshot {
+		if o, ok := objectMetaOf(entry.Meta); ok {
+			nodesByUID[o.GetUID()] = entry.Meta.ID
+		}
+	}
+
+	var edges []topology.Edge
+	for _, name := range sortedNames(snapshot) {
+		source := snapshot[name].Meta
+		o, ok := objectMetaOf(source)
+		if !ok {
+			continue
+		}
+		for _, ref := range o.GetOwnerReferences() {
+			if target, ok := nodesByUID[ref.UID]; ok {
+				edges = append(edges, newEdge(source.ID, target, edgeTypeOwnedBy))
+			}
+		}
+	}
+	return edges
+}
+
+// Creates the edge of the type from the source to the target node.
+func newEdge(source string, target string, edgeType string) topology.Edge {
+	return topology.Edge{ID: topology.EdgeID(source, target, edgeType), Source: source, Target: target, Type: edgeType}
+}
+
+// Drops the edges whose ID was seen before.
+func dedupeEdges(edges []topology.Edge) []topology.Edge {
+	seen := make(map[string]bool, len(edges))
+	var result []topology.Edge
+	for _, e := range edges {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Gets the metadata of the object of the node.
+func objectMetaOf(nm topology.NodeMeta) (metav1.Object, bool) {
+	obj, ok := nm.Value.(runtime.Object)
+	if !ok {
+		return nil, false
+	}
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, false
+	}
+	return o, true
+}
+
+// Gets the names of the cached services that the containers of the node refer
+// to in their environment, either by a *_SERVICE_HOST or *_SERVICE_PORT
+// variable or by a value that is the host of the service or a URL of it.
+// Every service the node refers to is added to the references, whether it is
+// cached or not.
+func getServiceReferences(c *kubeclient.Cache, node runtime.Object, references objectReferences) []string {
+	kind, ok := c.Kinds().KindOf(node)
+	if !ok || kind.PodTemplate == nil {
+		return nil
+	}
+	template := kind.PodTemplate(node)
+	if template == nil {
+		return nil
+	}
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return nil
+	}
+
+	services := make(map[string]bool)
+	for _, container := range template.Spec.Containers {
+		for _, env := range container.Env {
+			name := serviceNameOfVariable(env.Name)
+			if name == "" {
+				name = serviceNameOfHost(env.Value, o.GetNamespace())
+			}
+			if name == "" {
+				continue
+			}
+			references.add(o.GetNamespace(), "Service", name)
+			if _, ok := c.Get("Service", o.GetNamespace(), name); ok {
+				services[name] = true
+			}
+		}
+	}
+	return sortedKeys(services)
+}
+
+// Gets the name of the service of a variable that Kubernetes sets for it, e.g.
+// "database" for DATABASE_SERVICE_HOST.
+func serviceNameOfVariable(variable string) string {
+	for _, suffix := range serviceVariableSuffixes {
+		if strings.HasSuffix(variable, suffix) {
+			return strings.Replace(strings.ToLower(strings.TrimSuffix(variable, suffix)), "_", "-", -1)
+		}
+	}
+	return ""
+}
+
+// Gets the name of the service that a host refers to. The host must be given
+// as a URL, with a port or qualified with the namespace, e.g.
+// "http://database.myproject.svc:5432". Plain words such as "frontend" are
+// not taken for hosts since they are common values of other settings.
+func serviceNameOfHost(value string, namespace string) string {
+	host := value
+	qualified := false
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return ""
+		}
+		host = u.Hostname()
+		qualified = true
+	} else if i := strings.LastIndex(host, ":"); i >= 0 {
+		if _, err := strconv.ParseUint(host[i+1:], 10, 16); err != nil {
+			return ""
+		}
+		host = host[:i]
+		qualified = true
+	}
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1 && !qualified:
+		return ""
+	case len(parts) == 1:
+	case parts[1] != namespace:
+		return ""
+	case len(parts) > 2 && parts[2] != "svc":
+		return ""
+	}
+	return parts[0]
+}
+
+func sortedNames(snapshot topology.Snapshot) []string {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
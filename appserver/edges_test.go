@@ -0,0 +1,124 @@
+package appserver
+
+import (
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestServiceNameOfHost(t *testing.T) {
+	tests := map[string]string{
+		"database:5432":                            "database",
+		"database.myproject":                       "database",
+		"database.myproject.svc":                   "database",
+		"database.myproject.svc.cluster.local":     "database",
+		"postgresql://database.myproject.svc:5432": "database",
+		"http://database/api":                      "database",
+		"database.otherproject.svc":                "",
+		"www.example.com":                          "",
+		"frontend":                                 "",
+		"database:primary":                         "",
+	}
+	for value, expected := range tests {
+		require.Equal(t, expected, serviceNameOfHost(value, "myproject"), value)
+	}
+}
+
+func TestServiceNameOfVariable(t *testing.T) {
+	tests := map[string]string{
+		"DATABASE_SERVICE_HOST":    "database",
+		"MY_CACHE_SERVICE_PORT":    "my-cache",
+		"DATABASE_URL":             "",
+		"MODE":                     "",
+		"DATABASE_SERVICE_ACCOUNT": "",
+	}
+	for variable, expected := range tests {
+		require.Equal(t, expected, serviceNameOfVariable(variable), variable)
+	}
+}
+
+func TestAppServer_GetEdgeTypes(t *testing.T) {
+	deployment := func(name string, uid string, env ...corev1.EnvVar) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", UID: types.UID(uid), Labels: map[string]string{nameLabel: name}},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name, Env: env}}},
+				},
+			},
+		}
+	}
+	// The service is a resource of the node of the name and selects the pods
+	// of the app.
+	service := func(name string, node string, app string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", Labels: map[string]string{nameLabel: node}},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": app}},
+		}
+	}
+	frontend := deployment("frontend", "1",
+		corev1.EnvVar{Name: "DATABASE_URL", Value: "postgresql://database:5432/app"},
+		corev1.EnvVar{Name: "CACHE_SERVICE_HOST", Value: "10.0.0.1"},
+		corev1.EnvVar{Name: "MISSING_SERVICE_HOST", Value: "10.0.0.2"},
+	)
+	database := deployment("database", "2",
+		corev1.EnvVar{Name: "MODE", Value: "frontend"},
+		corev1.EnvVar{Name: "CACHE_SERVICE_PORT", Value: "6379"},
+	)
+	cache := deployment("cache", "3")
+	operator := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "myproject", UID: "4"},
+	}
+	// A deployment that is controlled by a node would be part of that node.
+	cache.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps.openshift.io/v1", Kind: "DeploymentConfig", Name: operator.Name, UID: operator.UID},
+	}
+	k := test.FakeKubeClient(frontend, database, cache, operator,
+		service("database", "database", "database"),
+		service("cache", "cache", "cache"),
+		service("frontend", "frontend", "frontend"),
+		// The frontend exposes the database with a service of its own.
+		service("frontend-database", "frontend", "database"),
+	)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	frontendID, databaseID, cacheID, operatorID := "myproject/Deployment/frontend", "myproject/Deployment/database", "myproject/Deployment/cache", "myproject/DeploymentConfig/operator"
+	require.ElementsMatch(t, []topology.Edge{
+		{ID: frontendID + "->" + databaseID + ":selects", Source: frontendID, Target: databaseID, Type: "selects"},
+		{ID: databaseID + "->" + cacheID + ":service-binding", Source: databaseID, Target: cacheID, Type: "service-binding"},
+		{ID: frontendID + "->" + cacheID + ":service-binding", Source: frontendID, Target: cacheID, Type: "service-binding"},
+		{ID: frontendID + "->" + databaseID + ":service-binding", Source: frontendID, Target: databaseID, Type: "service-binding"},
+		{ID: cacheID + "->" + operatorID + ":owned-by", Source: cacheID, Target: operatorID, Type: "owned-by"},
+	}, snapshot.Graph.Edges)
+
+	// All edges connect nodes of the graph.
+	nodes := make(map[string]bool)
+	for _, node := range snapshot.Graph.Nodes {
+		nodes[node.ID] = true
+	}
+	for _, edge := range snapshot.Graph.Edges {
+		require.True(t, nodes[edge.Source], edge.ID)
+		require.True(t, nodes[edge.Target], edge.ID)
+	}
+}
+
+func TestAppServer_GetAnnotationDataWithoutAnnotation(t *testing.T) {
+	nodejs := createResource("1", "DeploymentConfig", "nodejs", "testapp", "")
+	nodejs.Meta.Annotations = map[string]string{}
+	snapshot := topology.Snapshot{"nodejs": nodejs}
+
+	require.Empty(t, getAnnotationData(snapshot, "app.openshift.io/connects-to"))
+	require.Empty(t, getEdges(snapshot))
+}
@@ -0,0 +1,218 @@
+package appserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// nameLabel is the label of a node that other nodes refer to in their
+// connects-to annotation.
+const nameLabel = "app.kubernetes.io/name"
+
+// edgeRequest is the body of a request to create or delete a connects-to
+// edge. The source and target are node IDs. The source is the node whose
+// connects-to annotation names the target, so the streamed edge points the
+// other way, from the target to the source, see edge.
+type edgeRequest struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// ResourceVersion is the resource version of the source object that the
+	// client has seen. The edge is only changed if the source object still
+	// has that version. Without it the edge is changed in the latest version.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// Gets the edge of the topology that the request stands for. Edges lead from
+// the node that is named in a connects-to annotation to the annotated node.
+func (r edgeRequest) edge() topology.Edge {
+	return newEdge(r.Target, r.Source, edgeTypeConnectsTo)
+}
+
+// HandleCreateEdge returns the handler function for the POST /topology/edges
+// endpoint. It adds the name of the target node to the connects-to annotation
+// of the source node and responds with the edge from the target to the
+// source, which is part of the next update of the topology stream.
+func (srv *AppServer) HandleCreateEdge() http.HandlerFunc {
+	return srv.handleEdge(true)
+}
+
+// HandleDeleteEdge returns the handler function for the DELETE
+// /topology/edges endpoint. It removes the name of the target node from the
+// connects-to annotation of the source node.
+func (srv *AppServer) HandleDeleteEdge() http.HandlerFunc {
+	return srv.handleEdge(false)
+}
+
+// Returns the handler function that adds or removes connects-to edges.
+func (srv *AppServer) handleEdge(connect bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request edgeRequest
+		if err := decodeActionBody(w, r, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		source, target, err := srv.parseEdge(request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		k := srv.requireCallerKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		if err := setEdge(k, source, target, request.ResourceVersion, connect); err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return
+		}
+		if !connect {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		bytes, err := json.Marshal(request.edge())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(http.CanonicalHeaderKey("Content-Type"), "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytes)
+	}
+}
+
+// edgeNode is a node at one end of an edge.
+type edgeNode struct {
+	kind      kubeclient.Kind
+	namespace string
+	name      string
+}
+
+// Parses the nodes of the edge of the request. Names in the connects-to
+// annotation only refer to nodes of the same namespace.
+func (srv *AppServer) parseEdge(request edgeRequest) (edgeNode, edgeNode, error) {
+	source, err := srv.parseEdgeNode(request.Source)
+	if err != nil {
+		return edgeNode{}, edgeNode{}, errs.Wrap(err, "invalid source")
+	}
+	target, err := srv.parseEdgeNode(request.Target)
+	if err != nil {
+		return edgeNode{}, edgeNode{}, errs.Wrap(err, "invalid target")
+	}
+	if source.namespace != target.namespace {
+		return edgeNode{}, edgeNode{}, errs.Errorf("nodes %q and %q are not in the same namespace", request.Source, request.Target)
+	}
+	if request.Source == request.Target {
+		return edgeNode{}, edgeNode{}, errs.Errorf("node %q cannot connect to itself", request.Source)
+	}
+	return source, target, nil
+}
+
+// Parses the ID of a node of an edge.
+func (srv *AppServer) parseEdgeNode(id string) (edgeNode, error) {
+	namespace, kindName, name, err := topology.ParseNodeKey(id)
+	if err != nil {
+		return edgeNode{}, err
+	}
+	if namespace == "" {
+		return edgeNode{}, errs.Errorf("invalid node %q without a namespace", id)
+	}
+	kind, ok := srv.kinds.Lookup(kindName)
+	if !ok || !kind.Node {
+		return edgeNode{}, errs.Errorf("unknown node kind %s", kindName)
+	}
+	return edgeNode{kind: kind, namespace: namespace, name: name}, nil
+}
+
+// Adds the name of the target node to the connects-to annotation of the
+// source node, or removes it. The annotation is changed with a JSON merge
+// patch that carries the resource version of the source object, so the API
+// server rejects it if the object changed in the meantime. Without a resource
+// version of the client, the latest object is read again and patched until
+// the patch applies. Edges that already exist are not added again and edges
+// that do not exist are not removed, but a stale resource version of the
+// client is rejected either way.
+func setEdge(k *kubeclient.KubeClient, source edgeNode, target edgeNode, resourceVersion string, connect bool) error {
+	obj, err := k.GetObject(target.kind, target.namespace, target.name)
+	if err != nil {
+		return err
+	}
+	name := obj.GetLabels()[nameLabel]
+	if name == "" {
+		return apierrors.NewBadRequest("node " + topology.NodeKey(target.namespace, target.kind.Name, target.name) + " has no " + nameLabel + " label")
+	}
+
+	patch := func() error {
+		obj, err := k.GetObject(source.kind, source.namespace, source.name)
+		if err != nil {
+			return err
+		}
+		version := obj.GetResourceVersion()
+		if resourceVersion != "" && resourceVersion != version {
+			return apierrors.NewConflict(source.kind.Resource.GroupResource(), source.name, errs.Errorf("the object has version %s instead of %s", version, resourceVersion))
+		}
+		var names []string
+		if value, ok := obj.GetAnnotations()[connectsToAnnotation]; ok {
+			if err := json.Unmarshal([]byte(value), &names); err != nil {
+				return apierrors.NewBadRequest(errs.Wrapf(err, "invalid %s annotation of %s", connectsToAnnotation, source.name).Error())
+			}
+		}
+		names, changed := setName(names, name, connect)
+		if !changed {
+			return nil
+		}
+		var value interface{}
+		if len(names) > 0 {
+			bytes, err := json.Marshal(names)
+			if err != nil {
+				return errs.Wrapf(err, "failed to encode the %s annotation", connectsToAnnotation)
+			}
+			value = string(bytes)
+		}
+		bytes, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": version,
+				"annotations":     map[string]interface{}{connectsToAnnotation: value},
+			},
+		})
+		if err != nil {
+			return errs.Wrap(err, "failed to encode the patch")
+		}
+		return k.PatchObject(source.kind, source.namespace, source.name, types.MergePatchType, bytes)
+	}
+	if resourceVersion != "" {
+		return patch()
+	}
+	// The retry only recognizes conflicts that are not wrapped.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := patch()
+		if apierrors.IsConflict(errs.Cause(err)) {
+			return errs.Cause(err)
+		}
+		return err
+	})
+}
+
+// Adds the name to the names or removes it from them and reports whether the
+// names changed.
+func setName(names []string, name string, add bool) ([]string, bool) {
+	var result []string
+	for _, n := range names {
+		if n != name {
+			result = append(result, n)
+		}
+	}
+	if add {
+		if len(result) < len(names) {
+			return names, false
+		}
+		return append(result, name), true
+	}
+	return result, len(result) < len(names)
+}
@@ -0,0 +1,155 @@
+package appserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetEdge(t *testing.T) {
+	object := func(name string, annotation string) metav1.ObjectMeta {
+		o := metav1.ObjectMeta{Name: name, Namespace: "myproject", ResourceVersion: "1", Labels: map[string]string{nameLabel: name}}
+		if annotation != "" {
+			o.Annotations = map[string]string{connectsToAnnotation: annotation}
+		}
+		return o
+	}
+	unlabeled := object("perl", "")
+	unlabeled.Labels = nil
+	k := test.FakeKubeClient(
+		&appsv1.Deployment{ObjectMeta: object("nodejs", `["ruby"]`)},
+		&appsv1.Deployment{ObjectMeta: object("mongodb", "")},
+		&appsv1.Deployment{ObjectMeta: object("invalid", `ruby`)},
+		&appsv1.Deployment{ObjectMeta: unlabeled},
+		&corev1.Service{ObjectMeta: object("ruby", "")},
+	)
+	kinds := kubeclient.NewDefaultKindRegistry()
+	kind, _ := kinds.Lookup("Deployment")
+	node := func(name string) edgeNode {
+		return edgeNode{kind: kind, namespace: "myproject", name: name}
+	}
+	annotation := func(name string) (string, string) {
+		obj, err := k.GetObject(kind, "myproject", name)
+		require.NoError(t, err)
+		return obj.GetAnnotations()[connectsToAnnotation], obj.GetResourceVersion()
+	}
+
+	// The annotation of the source names the target.
+	require.NoError(t, setEdge(k, node("nodejs"), node("mongodb"), "1", true))
+	value, version := annotation("nodejs")
+	require.Equal(t, `["ruby","mongodb"]`, value)
+	require.Equal(t, "2", version)
+	_, version = annotation("mongodb")
+	require.Equal(t, "1", version)
+
+	// Existing edges are not added again.
+	require.NoError(t, setEdge(k, node("nodejs"), node("mongodb"), "", true))
+	_, version = annotation("nodejs")
+	require.Equal(t, "2", version)
+
+	// The source must not have changed since the version of the client, even
+	// if the edge would stay the same.
+	err := setEdge(k, node("nodejs"), node("mongodb"), "1", true)
+	require.Equal(t, http.StatusConflict, statusCodeOf(err))
+	err = setEdge(k, node("nodejs"), node("mongodb"), "1", false)
+	require.Equal(t, http.StatusConflict, statusCodeOf(err))
+
+	require.NoError(t, setEdge(k, node("nodejs"), node("mongodb"), "2", false))
+	value, _ = annotation("nodejs")
+	require.Equal(t, `["ruby"]`, value)
+
+	// The annotation is removed with the last edge.
+	ruby := edgeNode{namespace: "myproject", name: "ruby"}
+	ruby.kind, _ = kinds.Lookup("Service")
+	require.NoError(t, setEdge(k, node("nodejs"), ruby, "", false))
+	obj, err := k.GetObject(kind, "myproject", "nodejs")
+	require.NoError(t, err)
+	require.NotContains(t, obj.GetAnnotations(), connectsToAnnotation)
+
+	err = setEdge(k, node("mongodb"), node("perl"), "", true)
+	require.Equal(t, http.StatusBadRequest, statusCodeOf(err))
+	err = setEdge(k, node("invalid"), node("nodejs"), "", true)
+	require.Equal(t, http.StatusBadRequest, statusCodeOf(err))
+	err = setEdge(k, node("missing"), node("nodejs"), "", true)
+	require.Equal(t, http.StatusNotFound, statusCodeOf(err))
+	err = setEdge(k, node("nodejs"), node("missing"), "", true)
+	require.Equal(t, http.StatusNotFound, statusCodeOf(err))
+}
+
+func TestSetName(t *testing.T) {
+	names, changed := setName(nil, "nodejs", true)
+	require.True(t, changed)
+	require.Equal(t, []string{"nodejs"}, names)
+	names, changed = setName([]string{"ruby", "nodejs"}, "nodejs", true)
+	require.False(t, changed)
+	require.Equal(t, []string{"ruby", "nodejs"}, names)
+	names, changed = setName([]string{"nodejs", "ruby"}, "nodejs", false)
+	require.True(t, changed)
+	require.Equal(t, []string{"ruby"}, names)
+	_, changed = setName([]string{"ruby"}, "nodejs", false)
+	require.False(t, changed)
+}
+
+// The response to a request is the edge that the topology shows once the
+// annotation of the source names the target.
+func TestEdgeRequest_Edge(t *testing.T) {
+	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
+	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
+	edges := getEdges(topology.Snapshot{"nginx": nginx, "nodejs": nodejs})
+	require.Equal(t, []topology.Edge{edgeRequest{Source: "1", Target: "2"}.edge()}, edges)
+}
+
+func TestAppServer_EdgesBadRequest(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		for _, body := range []string{
+			``,
+			`{"source":"myproject/Deployment/nodejs"}`,
+			`{"source":"nodejs","target":"mongodb"}`,
+			`{"source":"Deployment/nodejs","target":"Deployment/mongodb"}`,
+			`{"source":"myproject/Unknown/nodejs","target":"myproject/Deployment/mongodb"}`,
+			`{"source":"myproject/Deployment/nodejs","target":"stage/Deployment/mongodb"}`,
+			`{"source":"myproject/Deployment/nodejs","target":"myproject/Deployment/nodejs"}`,
+			`{"source":"myproject/Deployment/nodejs","target":"myproject/Deployment/mongodb","type":"connects-to"}`,
+		} {
+			t.Run(method+" "+body, func(t *testing.T) {
+				r := httptest.NewRequest(method, "/topology/edges", strings.NewReader(body))
+				r.Header.Set("Authorization", "Bearer token")
+				rr := httptest.NewRecorder()
+				srv.Router().ServeHTTP(rr, r)
+				require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+			})
+		}
+	}
+}
+
+func TestAppServer_EdgesUnauthorized(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	body := `{"source":"myproject/Deployment/nodejs","target":"myproject/Deployment/mongodb"}`
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			// Browsers send the cookie along with requests of other sites.
+			r := httptest.NewRequest(method, "/topology/edges", strings.NewReader(body))
+			r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "token"})
+			rr := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rr, r)
+			require.Equal(t, http.StatusUnauthorized, rr.Code, rr.Body.String())
+		})
+	}
+}
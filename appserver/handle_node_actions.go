@@ -0,0 +1,375 @@
+package appserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// restartedAtAnnotation is the annotation of a pod template that is set
+	// to restart the pods of a workload, the same one that kubectl uses.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	// partOfLabel is the label that names the application of an object.
+	partOfLabel = "app.kubernetes.io/part-of"
+	// maxActionBodySize is the largest request body that an action reads.
+	maxActionBodySize = 1 << 20
+)
+
+// Kinds of nodes whose replicas can be scaled.
+var scalableKinds = map[string]bool{
+	"DeploymentConfig": true,
+	"Deployment":       true,
+	"StatefulSet":      true,
+}
+
+// Kinds of nodes whose pods can be restarted.
+var restartableKinds = map[string]bool{
+	"DeploymentConfig": true,
+	"Deployment":       true,
+	"StatefulSet":      true,
+	"DaemonSet":        true,
+}
+
+// Kinds of nodes that can be rolled back to an earlier revision.
+var rollbackKinds = map[string]bool{
+	"DeploymentConfig": true,
+	"Deployment":       true,
+}
+
+// Labels and annotations that the controllers add to the pod templates of
+// the revisions of a workload. They are not part of the template of the
+// workload itself.
+var (
+	revisionTemplateLabels = []string{
+		appsv1.DefaultDeploymentUniqueLabelKey,
+		"deployment",
+	}
+	revisionTemplateAnnotations = []string{
+		"openshift.io/deployment.name",
+		"openshift.io/deployment-config.name",
+		deploymentConfigVersionAnnotation,
+	}
+)
+
+// scaleRequest is the body of a request to scale a node.
+type scaleRequest struct {
+	Replicas *int32 `json:"replicas"`
+}
+
+// rollbackRequest is the body of a request to roll back a node. The previous
+// revision is rolled back to if no revision is given.
+type rollbackRequest struct {
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// deleteApplicationResponse is the response to the deletion of an
+// application. It is sent whether all objects were deleted or not.
+type deleteApplicationResponse struct {
+	// Deleted are the keys of the deleted objects.
+	Deleted []string `json:"deleted"`
+	// Errors tell why objects of the application were not deleted.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// jsonPatchOperation is an operation of a JSON patch (RFC 6902).
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// HandleScaleNode returns the handler function for the
+// /topology/nodes/{id}/scale endpoint. It sets the replicas of the workload of
+// the node to the replicas of the request body.
+func (srv *AppServer) HandleScaleNode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, namespace, name, ok := srv.requireNode(w, r, scalableKinds)
+		if !ok {
+			return
+		}
+		var request scaleRequest
+		if err := decodeActionBody(w, r, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Replicas == nil || *request.Replicas < 0 {
+			http.Error(w, "replicas must be zero or more", http.StatusBadRequest)
+			return
+		}
+		k := srv.requireCallerKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		if err := scaleNode(k, kind, namespace, name, *request.Replicas); err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleRestartNode returns the handler function for the
+// /topology/nodes/{id}/restart endpoint. It replaces the pods of the workload
+// of the node with new ones.
+func (srv *AppServer) HandleRestartNode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, namespace, name, ok := srv.requireNode(w, r, restartableKinds)
+		if !ok {
+			return
+		}
+		k := srv.requireCallerKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		if err := restartNode(k, kind, namespace, name, time.Now()); err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleRollbackNode returns the handler function for the
+// /topology/nodes/{id}/rollback endpoint. It rolls the workload of the node
+// back to the pod template of an earlier revision.
+func (srv *AppServer) HandleRollbackNode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, namespace, name, ok := srv.requireNode(w, r, rollbackKinds)
+		if !ok {
+			return
+		}
+		var request rollbackRequest
+		if err := decodeActionBody(w, r, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Revision < 0 {
+			http.Error(w, "revision must be positive", http.StatusBadRequest)
+			return
+		}
+		k := srv.requireCallerKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		if err := rollbackNode(srv.kinds, k, kind, namespace, name, request.Revision); err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleDeleteApplication returns the handler function for the
+// /topology/applications/{name} endpoint. It deletes all objects of the
+// application in the namespaces of the request, i.e. all objects whose
+// app.kubernetes.io/part-of label is the name of the application. If some
+// objects are not deleted, the status code is the one of the first error and
+// the response still lists the deleted objects.
+func (srv *AppServer) HandleDeleteApplication() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		application := mux.Vars(r)["name"]
+		if msgs := validation.IsValidLabelValue(application); application == "" || len(msgs) > 0 {
+			http.Error(w, "invalid application "+strings.Join(msgs, ", "), http.StatusBadRequest)
+			return
+		}
+		namespaces, err := namespacesParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if namespaces[0] == metav1.NamespaceAll {
+			http.Error(w, "applications are only deleted from the given namespaces", http.StatusBadRequest)
+			return
+		}
+		k := srv.requireCallerKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		deleted, failures := deleteApplication(srv.kinds, k, application, namespaces...)
+		response := deleteApplicationResponse{Deleted: deleted}
+		status := http.StatusOK
+		for _, err := range failures {
+			response.Errors = append(response.Errors, err.Error())
+		}
+		if len(failures) > 0 {
+			status = statusCodeOf(failures[0])
+		}
+		bytes, err := json.Marshal(&response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(http.CanonicalHeaderKey("Content-Type"), "application/json")
+		w.WriteHeader(status)
+		w.Write(bytes)
+	}
+}
+
+// Gets the kind, namespace and name of the node of the request or answers
+// the request with an error if there is no such node or the action is not
+// supported for nodes of its kind.
+func (srv *AppServer) requireNode(w http.ResponseWriter, r *http.Request, supported map[string]bool) (kubeclient.Kind, string, string, bool) {
+	namespace, kindName, name, err := topology.ParseNodeKey(mux.Vars(r)["id"])
+	if err == nil && namespace == "" {
+		err = errs.Errorf("invalid node %q without a namespace", mux.Vars(r)["id"])
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return kubeclient.Kind{}, "", "", false
+	}
+	kind, ok := srv.kinds.Lookup(kindName)
+	if !ok || !kind.Node {
+		http.Error(w, "unknown node kind "+kindName, http.StatusBadRequest)
+		return kubeclient.Kind{}, "", "", false
+	}
+	if !supported[kind.Name] {
+		http.Error(w, "the action is not supported for nodes of kind "+kind.Name, http.StatusBadRequest)
+		return kubeclient.Kind{}, "", "", false
+	}
+	return kind, namespace, name, true
+}
+
+// Decodes the JSON body of an action request into the value. An empty body
+// leaves the value as it is.
+func decodeActionBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxActionBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && err != io.EOF {
+		return errs.Wrap(err, "invalid request body")
+	}
+	return nil
+}
+
+// Sets the replicas of the workload.
+func scaleNode(k *kubeclient.KubeClient, kind kubeclient.Kind, namespace string, name string, replicas int32) error {
+	return patchObject(k, kind, namespace, name, jsonPatchOperation{Op: "add", Path: "/spec/replicas", Value: replicas})
+}
+
+// Replaces the pods of the workload. A deployment config rolls out its latest
+// version again while other workloads get their pod template annotated with
+// the time of the restart.
+func restartNode(k *kubeclient.KubeClient, kind kubeclient.Kind, namespace string, name string, now time.Time) error {
+	if kind.Name == "DeploymentConfig" {
+		return k.InstantiateDeploymentConfig(namespace, name)
+	}
+	obj, err := k.GetObject(kind, namespace, name)
+	if err != nil {
+		return err
+	}
+	restartedAt := now.UTC().Format(time.RFC3339)
+	path := "/spec/template/metadata/annotations"
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "template", "metadata", "annotations"); found {
+		return patchObject(k, kind, namespace, name, jsonPatchOperation{Op: "add", Path: path + "/" + escapeJSONPointer(restartedAtAnnotation), Value: restartedAt})
+	}
+	return patchObject(k, kind, namespace, name, jsonPatchOperation{Op: "add", Path: path, Value: map[string]string{restartedAtAnnotation: restartedAt}})
+}
+
+// Rolls the workload back to the pod template of the revision, or of the
+// revision before the latest one if the revision is 0.
+func rollbackNode(kinds *kubeclient.KindRegistry, k *kubeclient.KubeClient, kind kubeclient.Kind, namespace string, name string, revision int64) error {
+	revisionKind, ok := kinds.Lookup("ReplicaSet")
+	if kind.Name == "DeploymentConfig" {
+		revisionKind, ok = kinds.Lookup("ReplicationController")
+	}
+	if !ok {
+		return errs.Errorf("no kind of the revisions of kind %s", kind.Name)
+	}
+	obj, err := k.GetObject(kind, namespace, name)
+	if err != nil {
+		return err
+	}
+	list, err := k.ListDynamic(revisionKind.Resource, namespace, metav1.ListOptions{})
+	if err != nil {
+		return errs.Wrapf(err, "failed to list the revisions of %s %s/%s", kind.Name, namespace, name)
+	}
+	var revisions []unstructured.Unstructured
+	for _, item := range list.Items {
+		if ref := metav1.GetControllerOf(&item); ref != nil && ref.UID == obj.GetUID() {
+			revisions = append(revisions, item)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return getRevision(&revisions[i]) > getRevision(&revisions[j])
+	})
+
+	var target *unstructured.Unstructured
+	switch {
+	case revision == 0 && len(revisions) > 1:
+		target = &revisions[1]
+	case revision == 0:
+		return apierrors.NewBadRequest("no earlier revision to roll back to")
+	case len(revisions) > 0 && getRevision(&revisions[0]) == revision:
+		return apierrors.NewBadRequest(fmt.Sprintf("revision %d is the latest revision", revision))
+	}
+	for i := range revisions {
+		if revision != 0 && getRevision(&revisions[i]) == revision {
+			target = &revisions[i]
+		}
+	}
+	if target == nil {
+		return apierrors.NewNotFound(revisionKind.Resource.GroupResource(), fmt.Sprintf("%s revision %d", name, revision))
+	}
+	template, found, err := unstructured.NestedMap(target.Object, "spec", "template")
+	if err != nil || !found {
+		return errs.Errorf("revision %s has no pod template", target.GetName())
+	}
+	for _, label := range revisionTemplateLabels {
+		unstructured.RemoveNestedField(template, "metadata", "labels", label)
+	}
+	for _, annotation := range revisionTemplateAnnotations {
+		unstructured.RemoveNestedField(template, "metadata", "annotations", annotation)
+	}
+	return patchObject(k, kind, namespace, name, jsonPatchOperation{Op: "replace", Path: "/spec/template", Value: template})
+}
+
+// Deletes the objects of all kinds that are part of the application in the
+// namespaces and returns their keys. An error does not stop the deletion of
+// the objects of other kinds, so all errors are returned with the keys.
+func deleteApplication(kinds *kubeclient.KindRegistry, k *kubeclient.KubeClient, application string, namespaces ...string) ([]string, []error) {
+	selector := partOfLabel + "=" + application
+	deleted := []string{}
+	var failures []error
+	for _, namespace := range namespaces {
+		for _, kind := range kinds.Kinds() {
+			names, err := k.DeleteObjects(kind, namespace, selector)
+			for _, name := range names {
+				deleted = append(deleted, topology.NodeKey(namespace, kind.Name, name))
+			}
+			if err != nil {
+				failures = append(failures, err)
+			}
+		}
+	}
+	return deleted, failures
+}
+
+// Applies the JSON patch operations to the object.
+func patchObject(k *kubeclient.KubeClient, kind kubeclient.Kind, namespace string, name string, operations ...jsonPatchOperation) error {
+	patch, err := json.Marshal(operations)
+	if err != nil {
+		return errs.Wrap(err, "failed to encode the patch")
+	}
+	return k.PatchObject(kind, namespace, name, types.JSONPatchType, patch)
+}
+
+// Escapes a key for use in a JSON pointer (RFC 6901).
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,262 @@
+package appserver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	ocfakeappsclient "github.com/openshift/client-go/apps/clientset/versioned/typed/apps/v1/fake"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestScaleNode(t *testing.T) {
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	k := test.FakeKubeClient(deployment)
+	kind, _ := kubeclient.NewDefaultKindRegistry().Lookup("Deployment")
+
+	for _, replicas := range []int32{3, 0} {
+		require.NoError(t, scaleNode(k, kind, "myproject", "nodejs", replicas))
+		obj, err := k.GetObject(kind, "myproject", "nodejs")
+		require.NoError(t, err)
+		value, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		require.Equal(t, int64(replicas), value)
+	}
+}
+
+func TestRestartNode(t *testing.T) {
+	now := time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)
+	annotated := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"owner": "me"}},
+		}},
+	}
+	plain := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "fluentd", Namespace: "myproject"},
+	}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ruby", Namespace: "myproject"},
+	}
+	k := test.FakeKubeClient(annotated, plain, dc)
+	kinds := kubeclient.NewDefaultKindRegistry()
+
+	// The pod template is annotated whether it has annotations or not.
+	for kindName, name := range map[string]string{"Deployment": "nodejs", "DaemonSet": "fluentd"} {
+		kind, _ := kinds.Lookup(kindName)
+		require.NoError(t, restartNode(k, kind, "myproject", name, now))
+		obj, err := k.GetObject(kind, "myproject", name)
+		require.NoError(t, err)
+		annotations, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "annotations")
+		require.Equal(t, "2019-04-01T12:00:00Z", annotations[restartedAtAnnotation], kindName)
+		if kindName == "Deployment" {
+			require.Equal(t, "me", annotations["owner"])
+		}
+	}
+
+	// A deployment config rolls out its latest version again.
+	var request *deploymentconfigv1.DeploymentRequest
+	k.OcAppsClient.(*ocfakeappsclient.FakeAppsV1).PrependReactor("create", "deploymentconfigs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "instantiate" {
+			return false, nil, nil
+		}
+		request = action.(clienttesting.CreateAction).GetObject().(*deploymentconfigv1.DeploymentRequest)
+		return true, dc, nil
+	})
+	kind, _ := kinds.Lookup("DeploymentConfig")
+	require.NoError(t, restartNode(k, kind, "myproject", "ruby", now))
+	require.NotNil(t, request)
+	require.True(t, request.Latest)
+	require.True(t, request.Force)
+}
+
+func TestRollbackNode(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	replicaSet := func(revision string, image string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "nodejs-" + revision,
+				Namespace:       "myproject",
+				Annotations:     map[string]string{replicaSetRevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					"app":                                  "nodejs",
+					appsv1.DefaultDeploymentUniqueLabelKey: "hash-" + revision,
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nodejs", Image: image}}},
+			}},
+		}
+	}
+	other := replicaSet("4", "other:4")
+	other.Name = "other-4"
+	other.OwnerReferences = nil
+	k := test.FakeKubeClient(deployment,
+		replicaSet("1", "nodejs:1"),
+		replicaSet("2", "nodejs:2"),
+		replicaSet("3", "nodejs:3"),
+		other,
+	)
+	kinds := kubeclient.NewDefaultKindRegistry()
+	kind, _ := kinds.Lookup("Deployment")
+	image := func() string {
+		obj, err := k.GetObject(kind, "myproject", "nodejs")
+		require.NoError(t, err)
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		labels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+		require.Equal(t, map[string]string{"app": "nodejs"}, labels)
+		return containers[0].(map[string]interface{})["image"].(string)
+	}
+
+	// The previous revision is the default.
+	require.NoError(t, rollbackNode(kinds, k, kind, "myproject", "nodejs", 0))
+	require.Equal(t, "nodejs:2", image())
+
+	require.NoError(t, rollbackNode(kinds, k, kind, "myproject", "nodejs", 1))
+	require.Equal(t, "nodejs:1", image())
+
+	// Only earlier revisions of the workload itself can be rolled back to.
+	err := rollbackNode(kinds, k, kind, "myproject", "nodejs", 3)
+	require.Equal(t, http.StatusBadRequest, statusCodeOf(err))
+	err = rollbackNode(kinds, k, kind, "myproject", "nodejs", 4)
+	require.Equal(t, http.StatusNotFound, statusCodeOf(err))
+
+	k = test.FakeKubeClient(deployment, replicaSet("1", "nodejs:1"))
+	err = rollbackNode(kinds, k, kind, "myproject", "nodejs", 0)
+	require.Equal(t, http.StatusBadRequest, statusCodeOf(err))
+}
+
+func TestDeleteApplication(t *testing.T) {
+	object := func(namespace string, name string, application string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{partOfLabel: application}}
+	}
+	k := test.FakeKubeClient(
+		&deploymentconfigv1.DeploymentConfig{ObjectMeta: object("myproject", "nodejs", "testapp")},
+		&corev1.Service{ObjectMeta: object("myproject", "nodejs", "testapp")},
+		&appsv1.Deployment{ObjectMeta: object("myproject", "perl", "otherapp")},
+		&appsv1.Deployment{ObjectMeta: object("stage", "ruby", "testapp")},
+	)
+	kinds := kubeclient.NewDefaultKindRegistry()
+
+	deleted, failures := deleteApplication(kinds, k, "testapp", "myproject")
+	require.Empty(t, failures)
+	require.ElementsMatch(t, []string{"myproject/DeploymentConfig/nodejs", "myproject/Service/nodejs"}, deleted)
+
+	// Other applications and namespaces are left alone.
+	kind, _ := kinds.Lookup("Deployment")
+	_, err := k.GetObject(kind, "myproject", "perl")
+	require.NoError(t, err)
+	_, err = k.GetObject(kind, "stage", "ruby")
+	require.NoError(t, err)
+	kind, _ = kinds.Lookup("Service")
+	_, err = k.GetObject(kind, "myproject", "nodejs")
+	require.Equal(t, http.StatusNotFound, statusCodeOf(err))
+}
+
+func TestDeleteApplication_Failures(t *testing.T) {
+	object := metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: map[string]string{partOfLabel: "testapp"}}
+	k := test.FakeKubeClient(
+		&appsv1.Deployment{ObjectMeta: object},
+		&corev1.Service{ObjectMeta: object},
+		&corev1.Pod{ObjectMeta: object},
+	)
+	fake := k.DynamicClient.(*dynamicfake.FakeDynamicClient)
+	// The cluster does not serve the OpenShift kinds.
+	fake.PrependReactor("list", "deploymentconfigs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+	fake.PrependReactor("delete", "services", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), "nodejs", errors.New("denied"))
+	})
+
+	// The objects of other kinds are deleted all the same.
+	deleted, failures := deleteApplication(kubeclient.NewDefaultKindRegistry(), k, "testapp", "myproject")
+	require.ElementsMatch(t, []string{"myproject/Deployment/nodejs", "myproject/Pod/nodejs"}, deleted)
+	require.Len(t, failures, 1)
+	require.Equal(t, http.StatusForbidden, statusCodeOf(failures[0]))
+}
+
+func TestAppServer_NodeActionsBadRequest(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/topology/nodes/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/Deployment/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Unknown/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Service/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/DaemonSet/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/scale", ``},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/scale", `{"replicas":-1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/scale", `{"replicas":"1"}`},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/scale", `{"replica":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Job/nodejs/restart", ``},
+		{http.MethodPost, "/topology/nodes/myproject/StatefulSet/nodejs/rollback", ``},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/rollback", `{"revision":-1}`},
+		{http.MethodDelete, "/topology/applications/testapp", ``},
+		{http.MethodDelete, "/topology/applications/testapp?allNamespaces=true", ``},
+		{http.MethodDelete, "/topology/applications/test%20app?namespace=myproject", ``},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path+" "+tc.body, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			r.Header.Set("Authorization", "Bearer token")
+			rr := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rr, r)
+			require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestAppServer_NodeActionsUnauthorized(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/scale", `{"replicas":1}`},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/restart", ``},
+		{http.MethodPost, "/topology/nodes/myproject/Deployment/nodejs/rollback", ``},
+		{http.MethodDelete, "/topology/applications/testapp?namespace=myproject", ``},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			// Browsers send the cookie along with requests of other sites.
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "token"})
+			rr := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rr, r)
+			require.Equal(t, http.StatusUnauthorized, rr.Code, rr.Body.String())
+		})
+	}
+}
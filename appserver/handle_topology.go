@@ -1,156 +1,739 @@
 package appserver
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
-	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
 	routev1 "github.com/openshift/api/route/v1"
+	errs "github.com/pkg/errors"
 	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/configuration"
 	"github.com/redhat-developer/app-service/kubeclient"
 	"github.com/redhat-developer/app-service/watcher"
 
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/watch"
 	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 )
 
 var k8log = logf.Log
 
-type nodeMeta struct {
-	ID          string
-	Name        string
-	Type        string
-	Kind        string
-	Value       interface{}
-	Labels      map[string]string
-	Annotations map[string]string
-}
-
-type innerData struct {
-	nm nodeMeta
-	nd topology.NodeData
-}
+const (
+	// maxCloseReasonLength is the longest reason that fits into a close
+	// frame next to the close code.
+	maxCloseReasonLength = 123
+	// closeTimeout is the time given to send a close frame.
+	closeTimeout = time.Second
+	// maxOwnerDepth is the longest chain of controller owner references
+	// that is followed between a node and the objects it owns.
+	maxOwnerDepth = 4
+)
 
-type nodesMap struct {
-	nodes map[string]innerData
+// Kinds whose changes may change the data of the nodes that refer to them.
+var sharedKinds = map[string]bool{
+	"Service":     true,
+	"Route":       true,
+	"Ingress":     true,
+	"BuildConfig": true,
+	"Build":       true,
+	"ImageStream": true,
 }
 
+// upgrader only lets pages of the same origin open web sockets. The server
+// allows the configured origins too, see checkOrigin.
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	Subprotocols:    []string{},
 }
 
 // HandleTopology returns the handler function for the /status endpoint.
 func (srv *AppServer) HandleTopology() http.HandlerFunc {
 
+	upgrader := upgrader
+	upgrader.CheckOrigin = srv.checkOrigin
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Get the namespace and convert the connection to a web stocket.
-		namespace := r.FormValue("namespace")
-		ws := convertHTTPToWebSocket(w, r)
-		createTopology(ws, namespace)
+		// Create a client on behalf of the caller and validate the request
+		// before upgrading so that bad requests get a proper HTTP answer.
+		if !srv.checkOrigin(r) {
+			http.Error(w, fmt.Sprintf("origin %q is not allowed", r.Header.Get("Origin")), http.StatusForbidden)
+			return
+		}
+		k := srv.requireKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		namespaces, ok := srv.requireNamespaces(w, r, k)
+		if !ok {
+			return
+		}
+		filter, err := filterParam(r, srv.kinds)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		view, err := srv.topologyView(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		streamID, seq := r.FormValue("stream"), r.FormValue("seq")
+		if seq != "" {
+			if _, err := strconv.ParseUint(seq, 10, 64); err != nil {
+				http.Error(w, fmt.Sprintf("invalid sequence number %q", seq), http.StatusBadRequest)
+				return
+			}
+		}
+		cacheClient, ok := srv.requireCacheClient(w, k, namespaces, &filter)
+		if !ok {
+			return
+		}
+
+		// Convert the connection to a web socket. The upgrader has already
+		// answered the request if that fails.
+		ws, err := convertHTTPToWebSocket(&upgrader, w, r)
+		if err != nil {
+			srv.logger.Printf("failed to upgrade the connection to a web socket: %v", err)
+			return
+		}
+
+		// Get the shared cache of the namespaces.
+		c, err := srv.caches.Acquire(cacheClient, filter, namespaces...)
+		if err != nil {
+			srv.logger.Printf("failed to get the cache of namespaces %q: %v", namespaces, err)
+			closeWebSocket(ws, websocket.CloseInternalServerErr, err.Error())
+			return
+		}
+		defer srv.caches.Release(c)
+
+		// Stream until the client leaves.
+		createTopology(r.Context(), ws, c, view, srv.streams, k.Identity(), streamID, seq)
 	}
 }
 
-// Create and stream topology.
-func createTopology(ws *websocket.Conn, namespace string) {
+// Checks whether the page that the request comes from may open a topology
+// web socket. Browsers send the access_token cookie along with requests from
+// any page, so only the service itself and the configured origins may.
+// Clients other than browsers send no origin.
+func (srv *AppServer) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range srv.config.GetHTTPAllowedOrigins() {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Create a client.
-	k := kubeclient.NewKubeClient(upgrader.Subprotocols)
+// Gets the namespaces of the request and answers it if they are invalid or
+// the caller may not see all namespaces that it asks for.
+func (srv *AppServer) requireNamespaces(w http.ResponseWriter, r *http.Request, k *kubeclient.KubeClient) ([]string, bool) {
+	namespaces, err := namespacesParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if namespaces[0] == metav1.NamespaceAll {
+		allowed, err := k.CanList(srv.kinds, metav1.NamespaceAll)
+		if err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return nil, false
+		}
+		if !allowed {
+			http.Error(w, "not allowed to list the objects of all namespaces", http.StatusForbidden)
+			return nil, false
+		}
+	}
+	return namespaces, true
+}
+
+// Gets the client that fills the cache of the namespaces for the caller or
+// answers the request if the caller may not see them. The caches are filled
+// with the credentials of the service and shared by all callers, so that the
+// API server watches do not grow with the number of callers. The caller must
+// therefore be allowed to list and watch every kind in every namespace, and
+// the optional kinds that it may not list are left out of its cache. In the
+// token client mode the service has no credentials of its own, so callers
+// fill their caches with their own token and only share them with callers of
+// the same token.
+func (srv *AppServer) requireCacheClient(w http.ResponseWriter, k *kubeclient.KubeClient, namespaces []string, filter *kubeclient.Filter) (*kubeclient.KubeClient, bool) {
+	if srv.config.GetKubernetesClientMode() == configuration.KubernetesClientModeToken {
+		return k, true
+	}
+	var excluded []string
+	for _, namespace := range namespaces {
+		allowed, err := k.CanList(srv.kinds, namespace)
+		if err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return nil, false
+		}
+		if !allowed {
+			http.Error(w, fmt.Sprintf("not allowed to list the objects of namespace %q", namespace), http.StatusForbidden)
+			return nil, false
+		}
+		denied, err := k.DeniedOptionalKinds(srv.kinds, namespace)
+		if err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return nil, false
+		}
+		excluded = append(excluded, denied...)
+	}
+	sort.Strings(excluded)
+	filter.ExcludedKinds = dedupeStrings(excluded)
+	cacheClient, err := kubeclient.NewKubeClientForConfig(srv.kubeConfig)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return cacheClient, true
+}
 
-	// Create mutex.
-	mutex := &sync.Mutex{}
+// Gets the namespaces of the request. Either the namespace parameter is given
+// one or more times with valid namespace names, or allNamespaces is true, in
+// which case the only namespace is metav1.NamespaceAll.
+func namespacesParam(r *http.Request) ([]string, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, errs.Wrap(err, "invalid parameters")
+	}
+	namespaces := r.Form["namespace"]
+	if value := r.Form.Get("allNamespaces"); value != "" {
+		all, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errs.Errorf("invalid allNamespaces %q", value)
+		}
+		if all {
+			if len(namespaces) > 0 {
+				return nil, errs.New("namespace cannot be combined with allNamespaces")
+			}
+			return []string{metav1.NamespaceAll}, nil
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil, errs.New("missing namespace")
+	}
+	for _, namespace := range namespaces {
+		if namespace == "" {
+			return nil, errs.New("empty namespace, use allNamespaces=true for all namespaces")
+		}
+		if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			return nil, errs.Errorf("invalid namespace %q: %s", namespace, strings.Join(msgs, ", "))
+		}
+	}
+	return namespaces, nil
+}
 
-	// Create a node watcher.
-	newWatch := createNodeWatcher(namespace, k)
+// topologyView is how a client wants to see the topology.
+type topologyView struct {
+	// groupLabels group the nodes, see getGroups.
+	groupLabels []string
+	// fields are the parts of the resources that the client gets.
+	fields resourceFields
+}
 
-	var nMap nodesMap
-	nMap.nodes = make(map[string]innerData)
-	resourceWatchers := make(map[*watcher.Watch]nodeMeta)
+// Gets how the request wants to see the topology.
+func (srv *AppServer) topologyView(r *http.Request) (topologyView, error) {
+	fields, err := fieldsParam(r)
+	if err != nil {
+		return topologyView{}, err
+	}
+	return topologyView{groupLabels: srv.config.GetTopologyGroupLabels(), fields: fields}, nil
+}
+
+// Gets the filter of the request from its labelSelector, fieldSelector,
+// partOf and kinds parameters. The application that partOf names is added to
+// the label selector and kinds may be repeated or separated by commas. The
+// filter selects the nodes, whose resources are shown whether they match or
+// not.
+func filterParam(r *http.Request, kinds *kubeclient.KindRegistry) (kubeclient.Filter, error) {
+	var filter kubeclient.Filter
+	selector, err := labels.Parse(r.FormValue("labelSelector"))
+	if err != nil {
+		return filter, errs.Wrapf(err, "invalid labelSelector %q", r.FormValue("labelSelector"))
+	}
+	if partOf := r.FormValue("partOf"); partOf != "" {
+		requirement, err := labels.NewRequirement(partOfLabel, selection.Equals, []string{partOf})
+		if err != nil {
+			return filter, errs.Wrapf(err, "invalid partOf %q", partOf)
+		}
+		selector = selector.Add(*requirement)
+	}
+	filter.LabelSelector = selector.String()
+
+	fieldSelector, err := fields.ParseSelector(r.FormValue("fieldSelector"))
+	if err != nil {
+		return filter, errs.Wrapf(err, "invalid fieldSelector %q", r.FormValue("fieldSelector"))
+	}
+	filter.FieldSelector = fieldSelector.String()
+
+	for _, value := range r.Form["kinds"] {
+		for _, name := range strings.Split(value, ",") {
+			if k, ok := kinds.Lookup(name); !ok || !k.Node {
+				return filter, errs.Errorf("unknown node kind %q", name)
+			}
+			filter.Kinds = append(filter.Kinds, name)
+		}
+	}
+	sort.Strings(filter.Kinds)
+	filter.Kinds = dedupeStrings(filter.Kinds)
+	// The API server would only reject unsupported fields once the cache
+	// lists the objects.
+	if err := filter.Validate(kinds); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// Create and stream topology until the client goes away or the context is
+// done. If the client asks to resume a stream from a sequence number, the
+// messages it missed are sent first. The web socket is closed on return.
+func createTopology(ctx context.Context, ws *websocket.Conn, c *kubeclient.Cache, view topologyView, streams *streamRegistry, owner string, streamID string, seq string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Watch the connection so that the session ends when the client leaves.
+	var wg sync.WaitGroup
+	defer func() {
+		ws.Close()
+		wg.Wait()
+	}()
+	wg.Add(2)
 	go func() {
-		newWatch.ListenWatcher(func(event watch.Event) {
-			node := getNodeMetadata(event.Object)
-			// If event type was "deleted", delete the node. Otherwise,
-			// add or update the node.
-			if event.Type == "DELETED" {
-				nMap.deleteNode(node)
-			} else {
-				nMap.addOrUpdateNodeMeta(node)
+		defer wg.Done()
+		readPump(ws, cancel)
+	}()
+	go func() {
+		defer wg.Done()
+		pingPump(ctx, ws, cancel)
+	}()
+
+	// Subscribe to the changes of the cache before reading its current
+	// state so that no change gets lost in between.
+	newWatch := createCacheWatcher(ctx, c)
+	defer newWatch.StopWatch()
+
+	store := topology.NewStore()
+
+	// Resume the stream or start a new one. Once the session ends the stream
+	// can be resumed by another connection.
+	stream, missed := resumeStream(streams, owner, c.Scope(), streamID, seq)
+	defer streams.detach(stream)
+
+	// All messages are written by this goroutine. A failed write means that
+	// the client is gone.
+	writeMessage := func(msg *topology.StreamMessage) bool {
+		ws.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := ws.WriteJSON(msg); err != nil {
+			cancel()
+			return false
+		}
+		return true
+	}
+	writeTopology := func() {
+		if msg := stream.next(getTopology(store.Snapshot(), view)); msg != nil {
+			writeMessage(msg)
+		}
+	}
+	for i := range missed {
+		if !writeMessage(&missed[i]) {
+			return
+		}
+	}
+
+	buildTopology(store, c)
+	writeTopology()
+
+	newWatch.ListenWatcher(ctx, func(event watch.Event) {
+		handleEvent(store, c, event)
+		writeTopology()
+	})
+
+	// The watch of the cache ended while the client is still there, because
+	// the session fell behind the changes or the cache was released. The
+	// client can resume the stream from the last message it got.
+	if ctx.Err() == nil {
+		closeWebSocket(ws, websocket.CloseTryAgainLater, "the topology fell behind the changes, resume the stream")
+	}
+}
+
+// Build the initial topology from the cached objects.
+func buildTopology(store *topology.Store, c *kubeclient.Cache) {
+	for _, obj := range c.List() {
+		handleEvent(store, c, watch.Event{Type: watch.Added, Object: obj})
+	}
+}
+
+// Resume the stream with the given ID from the sequence number. A new stream
+// is created if the stream is unknown, belongs to somebody else, shows other
+// namespaces or cannot be resumed from there.
+func resumeStream(streams *streamRegistry, owner string, scope string, streamID string, seq string) (*topologyStream, []topology.StreamMessage) {
+	if streamID != "" {
+		if stream, ok := streams.resume(streamID, owner, scope); ok {
+			if n, err := strconv.ParseUint(seq, 10, 64); err == nil {
+				if missed, ok := stream.since(n); ok {
+					return stream, missed
+				}
+			}
+			stream.reset()
+			return stream, nil
+		}
+	}
+	return streams.create(owner, scope), nil
+}
+
+// Apply a change of the cache to the nodes and their resources. Events must be
+// applied to a store one after another.
+func handleEvent(store *topology.Store, c *kubeclient.Cache, event watch.Event) {
+	kinds := c.Kinds()
+	kind, _ := kinds.KindOf(event.Object)
+	controller, controlled := controllingNode(c, event.Object)
+	// Objects of owned kinds belong to the node that controls them, if any.
+	// Objects that the filter does not select are no nodes, but may still be
+	// resources of one.
+	isNode := kind.Node && !(kind.Owned && controlled) && c.Selects(event.Object)
+	if isNode {
+		node := getNodeMetadata(kinds, event.Object)
+		// If event type was "deleted", delete the node. Otherwise,
+		// add or update the node.
+		if event.Type == watch.Deleted {
+			store.Delete(node.Key())
+			return
+		}
+		_, exists := store.Get(node.Key())
+		addOrUpdateNodeMeta(store, node)
+
+		// A new node gets all its cached resources attached.
+		if !exists {
+			if lKey := node.Labels["app.kubernetes.io/name"]; lKey != "" {
+				for _, obj := range c.ByIndex(kubeclient.AppNameIndex, kubeclient.AppNameKey(node.Namespace, lKey)) {
+					addResourceToNode(kinds, store, node, getResource(kinds, obj))
+				}
+			}
+			refreshOwnedResources(store, c, node.Key())
+			refreshNodeData(store, c, node.Key())
+			return
+		}
+		addResourceToNode(kinds, store, node, getResource(kinds, event.Object))
+		refreshOwnedResources(store, c, node.Key())
+		refreshNodeData(store, c, node.Key())
+	} else {
+		if kind.Node {
+			// The object may have been a node before it got a controller or
+			// stopped matching the filter.
+			store.Delete(getNodeMetadata(kinds, event.Object).Key())
+		}
+		if controlled {
+			// Whatever happens below a node may change the objects it owns.
+			refreshOwnedResources(store, c, controller)
+		}
+	}
+
+	// Some objects are not tied to a single node.
+	if sharedKinds[kind.Name] {
+		for _, key := range referringNodes(store, c, event.Object) {
+			refreshNodeData(store, c, key)
+		}
+	}
+
+	// Owned objects are only attached through their owners and nodes are only
+	// resources of themselves, even if they share their name label with other
+	// nodes.
+	if kind.Owned || isNode {
+		return
+	}
+
+	// Find the nodes the resource belongs to.
+	o, ok := event.Object.(metav1.Object)
+	if !ok {
+		return
+	}
+	lKey := o.GetLabels()["app.kubernetes.io/name"]
+	if lKey == "" {
+		return
+	}
+	r := getResource(kinds, event.Object)
+	for _, nm := range getLabelData(store.Snapshot(), "app.kubernetes.io/name", lKey)[lKey] {
+		if nm.Namespace != o.GetNamespace() {
+			continue
+		}
+		if event.Type == watch.Deleted {
+			// If the event  type was "deleted" delete the resource.
+			deleteNodeResource(store, nm, r)
+		} else {
+			// If the event was to add or update, attach the
+			// resource to its node.
+			addResourceToNode(kinds, store, nm, r)
+		}
+	}
+}
+
+// Follows the controller owner references of the object up to the node that
+// controls it, e.g. from a pod to its replica set to its deployment, and
+// returns the key of that node.
+func controllingNode(c *kubeclient.Cache, obj runtime.Object) (string, bool) {
+	kinds := c.Kinds()
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		o, err := meta.Accessor(obj)
+		if err != nil {
+			return "", false
+		}
+		ref := metav1.GetControllerOf(o)
+		if ref == nil {
+			return "", false
+		}
+		kind, ok := kindOfOwner(kinds, ref)
+		if !ok {
+			return "", false
+		}
+		owner, ok := c.Get(kind.Name, o.GetNamespace(), ref.Name)
+		if !ok {
+			return "", false
+		}
+		// An owner of an owned kind may itself be controlled by a node.
+		if kind.Node && c.Selects(owner) {
+			if _, controlled := controllingNode(c, owner); !kind.Owned || !controlled {
+				return topology.NodeKey(o.GetNamespace(), kind.Name, ref.Name), true
 			}
+		}
+		obj = owner
+	}
+	return "", false
+}
 
-			// Get all the list options for each unique node name.
-			resourceOptsList := getResourcesListOptions(nMap.getLabelData("app.kubernetes.io/name", ""))
+// Gets the kind of the owner that the reference points to.
+func kindOfOwner(kinds *kubeclient.KindRegistry, ref *metav1.OwnerReference) (kubeclient.Kind, bool) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err == nil {
+		if kind, ok := kinds.LookupGroupKind(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}); ok {
+			return kind, true
+		}
+	}
+	return kinds.Lookup(ref.Kind)
+}
 
-			// Create and start all watchers for list options.
-			for opts, nm := range resourceOptsList {
-				resourceWatchers[createResourceWatcher(namespace, opts, k)] = nm
+// Replaces the resources of owned kinds of the node with the cached objects
+// that the node controls, counts its pods for the donut status and follows the
+// rollout of its revisions.
+func refreshOwnedResources(store *topology.Store, c *kubeclient.Cache, key string) {
+	entry, ok := store.Get(key)
+	if !ok {
+		return
+	}
+	node, ok := entry.Meta.Value.(runtime.Object)
+	if !ok {
+		return
+	}
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return
+	}
+
+	// Walk down the controller owner references from the node.
+	kinds := c.Kinds()
+	var owned []topology.Resource
+	var pods []*corev1.Pod
+	uids := []string{string(o.GetUID())}
+	for depth := 0; depth < maxOwnerDepth && len(uids) > 0; depth++ {
+		var next []string
+		for _, uid := range uids {
+			for _, obj := range c.ByIndex(kubeclient.OwnerIndex, uid) {
+				if kind, ok := kinds.KindOf(obj); ok && kind.Owned {
+					owned = append(owned, getResource(kinds, obj))
+				}
+				if pod, ok := obj.(*corev1.Pod); ok {
+					pods = append(pods, pod)
+				}
+				if child, err := meta.Accessor(obj); err == nil {
+					next = append(next, string(child.GetUID()))
+				}
 			}
+		}
+		uids = next
+	}
+	sortResources(owned)
+	rollout := getRolloutStatus(c, node)
 
-			// Listen to each resource watcher.
-			for w, rwNode := range resourceWatchers {
-				go func(rwNode nodeMeta, w *watcher.Watch) {
-					w.ListenWatcher(func(resourceEvent watch.Event) {
-
-						// Get the resource.
-						r := getResource(resourceEvent.Object)
-						if resourceEvent.Type == "DELETED" {
-
-							// If the event  type was "deleted" delete the resource.
-							nMap.deleteNodeResource(rwNode, r)
-						} else {
-							// If the event was to add create and add it.
-							item := nMap.nodes[rwNode.Name].nd
-							if item.ID == "" {
-								var resource []topology.Resource
-								resource = append(resource, getResource(rwNode.Value))
-								item = topology.NodeData{
-									Name:      rwNode.Name,
-									Resources: resource,
-									ID:        rwNode.ID,
-									Type:      rwNode.Type,
-									Data: topology.Data{
-										URL:          "dummy_url",
-										EditURL:      "dummy_edit_url",
-										BuilderImage: rwNode.Name,
-										DonutStatus:  make(map[string]string),
-									},
-								}
-								var iData innerData
-								iData.nm = rwNode
-								iData.nd = item
-								nMap.nodes[rwNode.Name] = iData
-							}
-							// If the resource does not exist yet, add it. Otherwise,
-							// update the old resource with the new one.
-							nMap.addOrUpdateNodeResource(rwNode.Name, r)
-						}
-
-						// Write the topology.
-						mutex.Lock()
-						ws.WriteJSON(topology.GetSampleTopology(nMap.getNode(), nMap.getResources(), nMap.getGroups(), nMap.getEdges()))
-						mutex.Unlock()
-					})
-				}(rwNode, w)
+	updateNode(kinds, store, key, func(entry *topology.StoreEntry) {
+		var resources []topology.Resource
+		for _, r := range entry.Data.Resources {
+			if kind, ok := kinds.Lookup(r.Kind); !ok || !kind.Owned {
+				resources = append(resources, r)
 			}
-		})
-	}()
+		}
+		entry.Data.Resources = append(resources, owned...)
+		entry.Data.Data.DonutStatus = getDonutStatus(pods)
+		entry.Data.Data.RolloutStatus = rollout
+	})
+}
+
+// Resolves the data of the node that depends on other objects: its URLs from
+// the routes and ingresses of its services, its edit URL from its annotation,
+// its builder image, the status of its latest build and the services it
+// belongs to or refers to.
+func refreshNodeData(store *topology.Store, c *kubeclient.Cache, key string) {
+	entry, ok := store.Get(key)
+	if !ok {
+		return
+	}
+	node, ok := entry.Meta.Value.(runtime.Object)
+	if !ok {
+		return
+	}
+	references := make(objectReferences)
+	urls := getNodeURLs(c, node, references)
+	builderImage := getBuilderImage(c, node, references)
+	build := getBuildStatus(c, node, references)
+	services := sortedKeys(getNodeServices(c, node))
+	for _, name := range services {
+		references.add(entry.Meta.Namespace, "Service", name)
+	}
+	serviceReferences := getServiceReferences(c, node, references)
+	updateNode(c.Kinds(), store, key, func(entry *topology.StoreEntry) {
+		entry.Data.Data.URLs = urls
+		entry.Data.Data.URL = ""
+		if len(urls) > 0 {
+			entry.Data.Data.URL = urls[0]
+		}
+		entry.Data.Data.EditURL = entry.Meta.Annotations[vcsURIAnnotation]
+		entry.Data.Data.BuilderImage = builderImage
+		entry.Data.Data.Build = build
+		entry.Services = services
+		entry.ServiceReferences = serviceReferences
+		entry.References = references
+	})
 }
 
-// Get topology resources.
-func (nMap nodesMap) getResources() map[topology.NodeID]topology.NodeData {
+// objectReferences collects the keys of the shared objects that the data of a
+// node is resolved from. A nil collector collects nothing.
+type objectReferences map[string]bool
+
+func (r objectReferences) add(namespace string, kind string, name string) {
+	if r != nil {
+		r[topology.NodeKey(namespace, kind, name)] = true
+	}
+}
+
+// Gets the keys of the nodes whose data depends on the shared object: the
+// nodes that referred to it when their data was last resolved and the nodes of
+// its namespace that may refer to it now.
+func referringNodes(store *topology.Store, c *kubeclient.Cache, obj runtime.Object) []string {
+	kind, ok := c.Kinds().KindOf(obj)
+	if !ok {
+		return nil
+	}
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	key := topology.NodeKey(o.GetNamespace(), kind.Name, o.GetName())
+	return store.Keys(func(entry topology.StoreEntry) bool {
+		if entry.References[key] {
+			return true
+		}
+		return entry.Meta.Namespace == o.GetNamespace() && mayReferTo(c, entry, obj)
+	})
+}
+
+// Checks whether the data of the node may be resolved from the shared object
+// although the node did not refer to it before. Image streams only matter
+// through the build configs of a node, which already refers to them.
+func mayReferTo(c *kubeclient.Cache, entry topology.StoreEntry, obj runtime.Object) bool {
+	node, ok := entry.Meta.Value.(runtime.Object)
+	if !ok {
+		return false
+	}
+	services := make(map[string]bool, len(entry.Services))
+	for _, name := range entry.Services {
+		services[name] = true
+	}
+	switch obj := obj.(type) {
+	case *corev1.Service:
+		kind, ok := c.Kinds().KindOf(node)
+		return ok && kind.PodTemplate != nil && selectsPods(obj, kind.PodTemplate(node))
+	case *routev1.Route:
+		return routesTo(obj, services)
+	case *extensionsv1beta1.Ingress:
+		return hasIngressBackend(obj, services)
+	case *buildv1.BuildConfig:
+		if name := entry.Meta.Labels[nameLabel]; name != "" && obj.Labels[nameLabel] == name {
+			return true
+		}
+		if obj.Spec.Output.To == nil {
+			return false
+		}
+		for _, image := range getNodeImages(c, node) {
+			if sameImage(c, obj.Namespace, *obj.Spec.Output.To, entry.Meta.Namespace, image, nil) {
+				return true
+			}
+		}
+	case *buildv1.Build:
+		name := obj.Labels[buildConfigLabel]
+		if obj.Status.Config != nil {
+			name = obj.Status.Config.Name
+		}
+		return entry.References[topology.NodeKey(obj.Namespace, "BuildConfig", name)]
+	}
+	return false
+}
+
+// Applies the update to the entry of an existing node, creating the data of
+// the node first if it has none yet.
+func updateNode(kinds *kubeclient.KindRegistry, store *topology.Store, key string, update func(entry *topology.StoreEntry)) {
+	if _, ok := store.Get(key); !ok {
+		return
+	}
+	store.Update(key, func(entry *topology.StoreEntry) {
+		if entry.Data.ID == "" {
+			entry.Data = newNodeData(kinds, entry.Meta)
+		}
+		update(entry)
+	})
+}
+
+// Compile the topology of a snapshot the way the view shows it.
+func getTopology(snapshot topology.Snapshot, view topologyView) topology.VisualizationResponse {
+	return topology.GetSampleTopology(getNode(snapshot), getResources(snapshot, view.fields), getGroups(snapshot, view.groupLabels), getEdges(snapshot))
+}
+
+// Get topology resources with the given fields. The resources of the snapshot
+// are changed in place.
+func getResources(snapshot topology.Snapshot, fields resourceFields) map[topology.NodeID]topology.NodeData {
 	resourceMap := make(map[topology.NodeID]topology.NodeData)
-	for _, iData := range nMap.nodes {
-		if iData.nd.ID != "" {
-			resourceMap[topology.NodeID(iData.nd.ID)] = iData.nd
+	for _, entry := range snapshot {
+		if entry.Data.ID != "" {
+			for i, r := range entry.Data.Resources {
+				entry.Data.Resources[i] = fields.project(r)
+			}
+			resourceMap[topology.NodeID(entry.Data.ID)] = entry.Data
 		}
 	}
 
@@ -158,63 +741,78 @@ func (nMap nodesMap) getResources() map[topology.NodeID]topology.NodeData {
 }
 
 // Get topology edges.
-func (nMap nodesMap) getEdges() []topology.Edge {
-	var edges []topology.Edge
-	sourceObjects := make(map[string][]nodeMeta)
-	targetObjects := make(map[string][]string)
-
-	// Arrange keys and target objects.
-	targetObjects = nMap.getAnnotationData("app.openshift.io/connects-to")
-
-	// Arrange keys and source objects.
-	for targetKey, _ := range targetObjects {
-		sourceObjects[targetKey] = append(sourceObjects[targetKey], nMap.getLabelData("app.kubernetes.io/name", targetKey)[targetKey]...)
+func getEdges(snapshot topology.Snapshot) []topology.Edge {
+	nodesByID := make(map[string]topology.NodeMeta, len(snapshot))
+	for _, entry := range snapshot {
+		nodesByID[entry.Meta.ID] = entry.Meta
 	}
 
+	// Arrange the target objects by the names they connect to and the source
+	// objects by their name.
+	targetObjects := getAnnotationData(snapshot, connectsToAnnotation)
+	sourceObjects := getLabelData(snapshot, nameLabel, "")
+
 	// Lookup the target key in the source key and
-	// construct the edge.
+	// construct the edge. Names only refer to nodes of the same namespace.
+	var edges []topology.Edge
 	for targetKey, targets := range targetObjects {
-		sourceObjects := sourceObjects[targetKey]
-
 		for _, target := range targets {
-			for _, source := range sourceObjects {
-				e := topology.Edge{ID: source.ID, Source: source.ID, Target: target, Type: "connects-to"}
-				edges = append(edges, e)
+			namespace := nodesByID[target].Namespace
+			for _, source := range sourceObjects[targetKey] {
+				if source.Namespace != namespace {
+					continue
+				}
+				edges = append(edges, newEdge(source.ID, target, edgeTypeConnectsTo))
 			}
 		}
 	}
 
-	return edges
+	edges = append(edges, getServiceEdges(snapshot)...)
+	edges = append(edges, getServiceBindingEdges(snapshot)...)
+	edges = append(edges, getOwnerEdges(snapshot)...)
+
+	return dedupeEdges(edges)
 }
 
-// Get topology groups.
-func (nMap nodesMap) getGroups() []topology.Group {
-	nodes := make(map[string][]nodeMeta)
+// Get topology groups. The first group label groups the nodes, e.g. into
+// applications, and every further label groups the nodes of a group into
+// nested groups, e.g. components and their instances. A node is part of the
+// groups of its labels up to the first label it does not have.
+func getGroups(snapshot topology.Snapshot, groupLabels []string) []topology.Group {
 	var groups []topology.Group
-	var groupNodes []string
-
-	// Get all nodes which belong to the same part-of collection.
-	nodes = nMap.getLabelData("app.kubernetes.io/part-of", "")
-	for groupName, nodeMetas := range nodes {
-		for _, nm := range nodeMetas {
-			groupNodes = append(groupNodes, nm.ID)
+	byID := make(map[string]int)
+	for _, key := range sortedNames(snapshot) {
+		nm := snapshot[key].Meta
+		parentID := ""
+		for _, label := range groupLabels {
+			value := nm.Labels[label]
+			if value == "" {
+				break
+			}
+			id := topology.GroupID(parentID, value)
+			index, ok := byID[id]
+			if !ok {
+				index = len(groups)
+				byID[id] = index
+				groups = append(groups, topology.Group{ID: id, Name: value, ParentID: parentID})
+				if parentID != "" {
+					parent := &groups[byID[parentID]]
+					parent.Groups = append(parent.Groups, id)
+				}
+			}
+			groups[index].Nodes = append(groups[index].Nodes, nm.ID)
+			parentID = id
 		}
-
-		// Create the group.
-		g := topology.Group{ID: "group:" + groupName, Name: groupName, Nodes: groupNodes}
-
-		// Append the group to the list of groups.
-		groups = append(groups, g)
 	}
 
 	return groups
 }
 
 // Create topology node.
-func (nMap nodesMap) getNode() []topology.Node {
+func getNode(snapshot topology.Snapshot) []topology.Node {
 	var nodes []topology.Node
-	for _, node := range nMap.nodes {
-		n := topology.Node{Name: node.nm.Name, ID: node.nm.ID}
+	for _, entry := range snapshot {
+		n := topology.Node{Name: entry.Meta.Name, ID: entry.Meta.ID, Namespace: entry.Meta.Namespace}
 		nodes = addOrUpdateNode(nodes, n)
 	}
 
@@ -222,16 +820,16 @@ func (nMap nodesMap) getNode() []topology.Node {
 }
 
 // Get node label data.
-func (nMap nodesMap) getLabelData(label string, keyLabel string) map[string][]nodeMeta {
-	labelMap := make(map[string][]nodeMeta)
-	for _, node := range nMap.nodes {
+func getLabelData(snapshot topology.Snapshot, label string, keyLabel string) map[string][]topology.NodeMeta {
+	labelMap := make(map[string][]topology.NodeMeta)
+	for _, entry := range snapshot {
 
-		lkey := node.nm.Labels[label]
+		lkey := entry.Meta.Labels[label]
 		if lkey != "" {
 			if keyLabel == "" {
-				labelMap[lkey] = append(labelMap[lkey], node.nm)
+				labelMap[lkey] = append(labelMap[lkey], entry.Meta)
 			} else if keyLabel == lkey {
-				labelMap[lkey] = append(labelMap[lkey], node.nm)
+				labelMap[lkey] = append(labelMap[lkey], entry.Meta)
 			}
 		}
 	}
@@ -240,70 +838,105 @@ func (nMap nodesMap) getLabelData(label string, keyLabel string) map[string][]no
 }
 
 // Get node annotation data.
-func (nMap nodesMap) getAnnotationData(annotation string) map[string][]string {
+func getAnnotationData(snapshot topology.Snapshot, annotation string) map[string][]string {
 	annotationsMap := make(map[string][]string)
-	for _, node := range nMap.nodes {
+	for _, entry := range snapshot {
+		value, ok := entry.Meta.Annotations[annotation]
+		if !ok {
+			continue
+		}
 		var keys []string
-		err := json.Unmarshal([]byte(node.nm.Annotations[annotation]), &keys)
+		err := json.Unmarshal([]byte(value), &keys)
 		if err != nil {
-			k8log.Error(err, "failed to retrieve json dencoding of node")
+			k8log.Error(err, "failed to retrieve json dencoding of node annotation", "node", entry.Meta.Name, "annotation", annotation)
 		}
 		for _, key := range keys {
-			annotationsMap[key] = append(annotationsMap[key], node.nm.ID)
+			annotationsMap[key] = append(annotationsMap[key], entry.Meta.ID)
 		}
 	}
 
 	return annotationsMap
 }
 
-// Delete entire node.
-func (nMap nodesMap) deleteNode(nm nodeMeta) {
-	delete(nMap.nodes, nm.Name)
+// Compare and add if resource does not exist or update if resource does exist.
+func addOrUpdateNodeMeta(store *topology.Store, node topology.NodeMeta) {
+	store.Update(node.Key(), func(entry *topology.StoreEntry) {
+		entry.Meta = node
+	})
 }
 
-// Compare and add if resource does not exist or update if resource does exist.
-func (nMap nodesMap) addOrUpdateNodeMeta(node nodeMeta) {
-	iData := nMap.nodes[node.Name]
-	iData.nm = node
-	nMap.nodes[node.Name] = iData
+// Delete a single resource on node. Other resources of the same kind stay.
+func deleteNodeResource(store *topology.Store, nm topology.NodeMeta, r topology.Resource) {
+	if _, ok := store.Get(nm.Key()); !ok {
+		return
+	}
+	store.Update(nm.Key(), func(entry *topology.StoreEntry) {
+		var newSlice []topology.Resource
+		for _, resource := range entry.Data.Resources {
+			if resource.Key() != r.Key() {
+				newSlice = append(newSlice, resource)
+			}
+		}
+		entry.Data.Resources = newSlice
+	})
 }
 
-// Delete a single resource on node.
-func (nMap nodesMap) deleteNodeResource(nm nodeMeta, r topology.Resource) {
-	var newSlice []topology.Resource
-	nodeData := nMap.nodes[nm.Name].nd
-	for _, resource := range nodeData.Resources {
-		if resource.Kind != r.Kind {
-			newSlice = append(newSlice, resource)
+// Add a resource to the node, creating the node data on the first resource.
+func addResourceToNode(kinds *kubeclient.KindRegistry, store *topology.Store, nm topology.NodeMeta, r topology.Resource) {
+	store.Update(nm.Key(), func(entry *topology.StoreEntry) {
+		if entry.Data.ID == "" {
+			entry.Meta = nm
+			entry.Data = newNodeData(kinds, nm)
 		}
-	}
+		// If the resource does not exist yet, add it. Otherwise,
+		// update the old resource with the new one.
+		entry.Data.Resources = addOrUpdateNodeResource(entry.Data.Resources, r)
+	})
+}
 
-	nodeData.Resources = newSlice
-	iData := nMap.nodes[nm.Name]
-	iData.nd = nodeData
-	nMap.nodes[nm.Name] = iData
+// Create the data of a node that shows the object of the node as its only
+// resource.
+func newNodeData(kinds *kubeclient.KindRegistry, nm topology.NodeMeta) topology.NodeData {
+	return topology.NodeData{
+		Name:      nm.Name,
+		Namespace: nm.Namespace,
+		Resources: []topology.Resource{getResource(kinds, nm.Value)},
+		ID:        nm.ID,
+		Type:      nm.Type,
+		Data: topology.Data{
+			EditURL:     nm.Annotations[vcsURIAnnotation],
+			DonutStatus: make(map[string]string),
+		},
+	}
 }
 
 // Compare and add if resource does not exist or update if resource does exist.
-func (nMap nodesMap) addOrUpdateNodeResource(name string, r topology.Resource) {
-
-	for index, element := range nMap.nodes[name].nd.Resources {
-		if element.Kind == r.Kind {
-			nMap.nodes[name].nd.Resources[index] = r
-			return
+// Resources are the same if they are made of the same object. The revisions of
+// a kind are kept from the oldest to the latest.
+func addOrUpdateNodeResource(resources []topology.Resource, r topology.Resource) []topology.Resource {
+	for index, element := range resources {
+		if element.Key() == r.Key() {
+			resources[index] = r
+			return resources
+		}
+	}
+	if r.Revision > 0 {
+		for index, element := range resources {
+			if element.Kind == r.Kind && element.Revision > r.Revision {
+				resources = append(resources, topology.Resource{})
+				copy(resources[index+1:], resources[index:])
+				resources[index] = r
+				return resources
+			}
 		}
 	}
-	node := nMap.nodes[name]
-	resources := node.nd.Resources
-	resources = append(resources, r)
-	node.nd.Resources = resources
-	nMap.nodes[name] = node
+	return append(resources, r)
 }
 
 // Compare and add if resource does not exist or update if resource does exist.
 func addOrUpdateNode(nodes []topology.Node, node topology.Node) []topology.Node {
 	for i, n := range nodes {
-		if n.Name == node.Name {
+		if n.ID == node.ID {
 			nodes[i] = n
 			return nodes
 		}
@@ -313,209 +946,98 @@ func addOrUpdateNode(nodes []topology.Node, node topology.Node) []topology.Node
 }
 
 // Converts the HTTP connection to a websocket in order to stream data.
-func convertHTTPToWebSocket(w http.ResponseWriter, r *http.Request) *websocket.Conn {
-	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
-	header := r.Header.Get("Sec-Websocket-Protocol")
-	secWsProtocol := strings.Split(header, ", ")
-	upgrader.Subprotocols = append(upgrader.Subprotocols, secWsProtocol...)
+func convertHTTPToWebSocket(upgrader *websocket.Upgrader, w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		panic(err)
+		return nil, errs.Wrap(err, "failed to upgrade the connection")
 	}
-	return ws
+	return ws, nil
 }
 
-// Create a watcher for Deployments and DeploymentConfigs.
-func createNodeWatcher(namespace string, k *kubeclient.KubeClient) *watcher.Watch {
-	listOptions := metav1.ListOptions{}
-	onGetWatchError := func(err error) {
-		fmt.Errorf("Error is %+v", err)
+// Closes the web socket with a close frame that tells the client the reason.
+func closeWebSocket(ws *websocket.Conn, code int, reason string) {
+	if len(reason) > maxCloseReasonLength {
+		reason = reason[:maxCloseReasonLength]
 	}
-	newWatch := watcher.NewWatch(namespace,
-		k,
-		k.GetDeploymentConfigWatcher(namespace, listOptions, onGetWatchError),
-		k.GetDeploymentWatcher(namespace, listOptions, onGetWatchError),
-	)
-	newWatch.SetFilters([]watch.EventType{watch.Added, watch.Modified, watch.Deleted})
-	newWatch.StartWatcher()
-
-	return newWatch
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(closeTimeout)); err != nil {
+		k8log.Error(err, "failed to send the close frame")
+	}
+	ws.Close()
 }
 
-// Create a watcher for all resources.
-func createResourceWatcher(namespace string, opts metav1.ListOptions, k *kubeclient.KubeClient) *watcher.Watch {
-	onGetWatchError := func(err error) {
-		fmt.Errorf("Error is %+v", err)
-	}
-	newWatch := watcher.NewWatch(namespace,
-		k,
-		k.GetDeploymentConfigWatcher(namespace, opts, onGetWatchError),
-		k.GetDeploymentWatcher(namespace, opts, onGetWatchError),
-		k.GetReplicationControllerWatcher(namespace, opts, onGetWatchError),
-		k.GetReplicaSetWatcher(namespace, opts, onGetWatchError),
-		k.GetServiceWatcher(namespace, opts, onGetWatchError),
-		k.GetRouteWatcher(namespace, opts, onGetWatchError),
-	)
+// Create a watcher for the changes of a shared cache.
+func createCacheWatcher(ctx context.Context, c *kubeclient.Cache) *watcher.Watch {
+	newWatch := watcher.NewWatch(metav1.NamespaceAll, nil, c.Watch())
 	newWatch.SetFilters([]watch.EventType{watch.Added, watch.Modified, watch.Deleted})
-	newWatch.StartWatcher()
+	newWatch.SetErrorHandler(func(err error) {
+		k8log.Error(err, "failed to watch the cache", "namespaces", c.Namespaces())
+	})
+	newWatch.StartWatcher(ctx)
 
 	return newWatch
 }
 
-// Compile the metav1.ListOptions for resources.
-func getResourcesListOptions(nMetaMap map[string][]nodeMeta) map[metav1.ListOptions]nodeMeta {
-	listOptions := make(map[metav1.ListOptions]nodeMeta)
-
-	// For each node, create the metav1.ListOptions based off
-	// the app.kubernetes.io/name label.
-	for lKey, nMetas := range nMetaMap {
-		if lKey != "" {
-			options := metav1.ListOptions{
-				LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s", lKey),
-			}
-			for _, meta := range nMetas {
-				listOptions[options] = meta
-			}
-		}
-	}
-
-	return listOptions
-}
-
 // Gets node metadata.
-func getNodeMetadata(x interface{}) nodeMeta {
-	var node nodeMeta
-	switch x.(type) {
-	case *deploymentconfigv1.DeploymentConfig:
-		dc := x.(*deploymentconfigv1.DeploymentConfig)
-		node = nodeMeta{
-			ID:          base64.StdEncoding.EncodeToString([]byte(dc.UID)),
-			Name:        dc.Name,
-			Kind:        "DeploymentConfig",
-			Type:        "workload",
-			Value:       x,
-			Labels:      dc.Labels,
-			Annotations: dc.Annotations,
-		}
-	case *appsv1.Deployment:
-		d := x.(*appsv1.Deployment)
-		node = nodeMeta{
-			ID:          base64.StdEncoding.EncodeToString([]byte(d.UID)),
-			Name:        d.Name,
-			Kind:        "Deployment",
-			Type:        "workload",
-			Value:       x,
-			Labels:      d.Labels,
-			Annotations: d.Annotations,
-		}
-	default:
+func getNodeMetadata(kinds *kubeclient.KindRegistry, x runtime.Object) topology.NodeMeta {
+	kind, ok := kinds.KindOf(x)
+	if !ok {
 		k8log.Info(fmt.Sprintf("failed to recognize node type: %s", reflect.TypeOf(x)))
+		return topology.NodeMeta{}
+	}
+	o, err := meta.Accessor(x)
+	if err != nil {
+		k8log.Error(err, "failed to retrieve the metadata of node")
+		return topology.NodeMeta{}
+	}
+	return topology.NodeMeta{
+		ID:          topology.NodeKey(o.GetNamespace(), kind.Name, o.GetName()),
+		Name:        o.GetName(),
+		Namespace:   o.GetNamespace(),
+		Kind:        kind.Name,
+		Type:        kind.NodeType,
+		Value:       x,
+		Labels:      o.GetLabels(),
+		Annotations: o.GetAnnotations(),
 	}
-
-	return node
 }
 
 // Create topology resources.
-func getResource(rx interface{}) topology.Resource {
-	var r topology.Resource
-	switch rx.(type) {
-	case *deploymentconfigv1.DeploymentConfig:
-		deploymentConfig := rx.(*deploymentconfigv1.DeploymentConfig)
-		meta, err := json.Marshal(deploymentConfig.GetObjectMeta())
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of deployment config")
-		}
-		status, err := json.Marshal(deploymentConfig.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of deployment config")
-		}
-		r = topology.Resource{
-			Name:     deploymentConfig.Name,
-			Kind:     "DeploymentConfig",
-			Metadata: string(meta),
-			Status:   string(status),
-		}
-	case *appsv1.Deployment:
-		deployment := rx.(*appsv1.Deployment)
-		meta, err := json.Marshal(deployment.GetObjectMeta())
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of deployment")
-		}
-		status, err := json.Marshal(deployment.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of deployment")
-		}
-		r = topology.Resource{
-			Name:     deployment.Name,
-			Kind:     "Deployment",
-			Metadata: string(meta),
-			Status:   string(status),
-		}
-	case *corev1.Service:
-		serv := rx.(*corev1.Service)
-		meta, err := json.Marshal(serv.GetObjectMeta())
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of service")
-		}
-		status, err := json.Marshal(serv.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of service")
-		}
-		r = topology.Resource{
-			Name:     serv.Name,
-			Kind:     "Service",
-			Metadata: string(meta),
-			Status:   string(status),
-		}
-	case *routev1.Route:
-		route := rx.(*routev1.Route)
-		meta, err := json.Marshal(route.GetObjectMeta())
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of route")
-		}
-		status, err := json.Marshal(route.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of route")
-		}
-		r = topology.Resource{Name: route.Name,
-			Kind:     "Route",
-			Metadata: string(meta),
-			Status:   string(status),
-		}
-	case *corev1.ReplicationController:
-		rc := rx.(*corev1.ReplicationController)
-		meta, err := json.Marshal(rc.GetObjectMeta())
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of replication controller")
-		}
-		status, err := json.Marshal(rc.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of replication controller")
-		}
-		r = topology.Resource{
-			Name:     rc.Name,
-			Kind:     "ReplicationController",
-			Metadata: string(meta),
-			Status:   string(status),
-		}
-	case *appsv1.ReplicaSet:
-		rs := rx.(*appsv1.ReplicaSet)
-		meta, err := json.Marshal(rs.GetObjectMeta())
+func getResource(kinds *kubeclient.KindRegistry, rx interface{}) topology.Resource {
+	obj, _ := rx.(runtime.Object)
+	kind, ok := kinds.KindOf(obj)
+	if !ok {
+		k8log.Info(fmt.Sprintf("failed to recognize resource type: %s", reflect.TypeOf(rx)))
+		return topology.Resource{}
+	}
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		k8log.Error(err, "failed to retrieve the metadata of resource", "kind", kind.Name)
+		return topology.Resource{}
+	}
+	metadata, err := json.Marshal(kind.MetadataOf(obj))
+	if err != nil {
+		k8log.Error(err, "failed to retrieve json encoding of resource metadata", "kind", kind.Name)
+	}
+	status, err := json.Marshal(kind.Status(obj))
+	if err != nil {
+		k8log.Error(err, "failed to retrieve json encoding of resource status", "kind", kind.Name)
+	}
+	var spec []byte
+	if kind.Spec != nil && kind.Spec(obj) != nil {
+		spec, err = json.Marshal(kind.Spec(obj))
 		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of replica set")
+			k8log.Error(err, "failed to retrieve json encoding of resource spec", "kind", kind.Name)
 		}
-		status, err := json.Marshal(rs.Status)
-		if err != nil {
-			k8log.Error(err, "failed to retrieve json encoding of replica set")
-		}
-		r = topology.Resource{
-			Name:     rs.Name,
-			Kind:     "ReplicaSet",
-			Metadata: string(meta),
-			Status:   string(status)}
-	default:
-		k8log.Info(fmt.Sprintf("failed to recognize resource type: %s", reflect.TypeOf(rx)))
 	}
-
-	return r
+	return topology.Resource{
+		Name:      o.GetName(),
+		Namespace: o.GetNamespace(),
+		Kind:      kind.Name,
+		Metadata:  metadata,
+		Status:    status,
+		Spec:      spec,
+		Summary:   getResourceSummary(kind, obj),
+		Revision:  getRevision(obj),
+	}
 }
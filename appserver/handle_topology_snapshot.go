@@ -0,0 +1,85 @@
+package appserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// HandleTopologySnapshot returns the handler function for the
+// /topology/snapshot endpoint. Unlike /topology it does not upgrade the
+// connection to a web socket but lists the resources once and responds with
+// the same topology that the stream would send.
+func (srv *AppServer) HandleTopologySnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		k := srv.requireKubeClient(w, r)
+		if k == nil {
+			return
+		}
+		namespaces, ok := srv.requireNamespaces(w, r, k)
+		if !ok {
+			return
+		}
+		filter, err := filterParam(r, srv.kinds)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		view, err := srv.topologyView(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		snapshot, err := getTopologySnapshot(srv.kinds, k, view, filter, namespaces...)
+		if err != nil {
+			http.Error(w, err.Error(), statusCodeOf(err))
+			return
+		}
+		bytes, err := json.Marshal(&snapshot)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(http.CanonicalHeaderKey("Content-Type"), "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytes)
+	}
+}
+
+// Gets the HTTP status code for an error of the API server so that a caller
+// who may not list or change the resources learns about it.
+func statusCodeOf(err error) int {
+	switch {
+	case apierrors.IsUnauthorized(errs.Cause(err)):
+		return http.StatusUnauthorized
+	case apierrors.IsForbidden(errs.Cause(err)):
+		return http.StatusForbidden
+	case apierrors.IsBadRequest(errs.Cause(err)):
+		return http.StatusBadRequest
+	case apierrors.IsNotFound(errs.Cause(err)):
+		return http.StatusNotFound
+	case apierrors.IsConflict(errs.Cause(err)):
+		return http.StatusConflict
+	case apierrors.IsInvalid(errs.Cause(err)):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Lists the filtered objects of the namespaces once and compiles the topology
+// the same way the stream does.
+func getTopologySnapshot(kinds *kubeclient.KindRegistry, k *kubeclient.KubeClient, view topologyView, filter kubeclient.Filter, namespaces ...string) (topology.VisualizationResponse, error) {
+	c, err := kubeclient.ListCache(k, kinds, filter, namespaces...)
+	if err != nil {
+		return topology.VisualizationResponse{}, err
+	}
+	store := topology.NewStore()
+	buildTopology(store, c)
+	return getTopology(store.Snapshot(), view), nil
+}
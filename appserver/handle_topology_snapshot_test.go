@@ -0,0 +1,488 @@
+package appserver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAppServer_GetTopologySnapshot(t *testing.T) {
+	labels := map[string]string{
+		"app.kubernetes.io/name":    "nodejs",
+		"app.kubernetes.io/part-of": "testapp",
+	}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	rc := &corev1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs-1", Namespace: "myproject", Labels: labels},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	unrelated := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myproject"},
+	}
+	k := test.FakeKubeClient(dc, rc, service, route, unrelated)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	require.Len(t, snapshot.Graph.Nodes, 1)
+	require.Equal(t, "nodejs", snapshot.Graph.Nodes[0].Name)
+	require.Len(t, snapshot.Graph.Groups, 1)
+	require.Equal(t, "testapp", snapshot.Graph.Groups[0].Name)
+
+	nodeData := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)]
+	require.Equal(t, "nodejs", nodeData.Name)
+	require.Equal(t, "workload", nodeData.Type)
+	kinds := make(map[string]bool)
+	for _, r := range nodeData.Resources {
+		kinds[r.Kind] = true
+	}
+	require.Equal(t, map[string]bool{
+		"DeploymentConfig":      true,
+		"ReplicationController": true,
+		"Service":               true,
+		"Route":                 true,
+	}, kinds)
+}
+
+func TestAppServer_GetTopologySnapshotSameKindResources(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	var objects []runtime.Object
+	for _, version := range []string{"10", "2", "1"} {
+		objects = append(objects, &corev1.ReplicationController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "nodejs-" + version,
+				Namespace:   "myproject",
+				Labels:      labels,
+				Annotations: map[string]string{deploymentConfigVersionAnnotation: version},
+			},
+		})
+	}
+	for _, name := range []string{"nodejs", "nodejs-metrics"} {
+		objects = append(objects, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", Labels: labels},
+		})
+	}
+	k := test.FakeKubeClient(append(objects, dc)...)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+
+	// Resources of the same kind are all kept and the revisions are in
+	// order.
+	var resources []string
+	for _, r := range snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Resources {
+		resources = append(resources, r.Key())
+	}
+	require.ElementsMatch(t, []string{
+		"myproject/DeploymentConfig/nodejs",
+		"myproject/Service/nodejs",
+		"myproject/Service/nodejs-metrics",
+		"myproject/ReplicationController/nodejs-1",
+		"myproject/ReplicationController/nodejs-2",
+		"myproject/ReplicationController/nodejs-10",
+	}, resources)
+	var revisions []string
+	for _, key := range resources {
+		if strings.Contains(key, "ReplicationController") {
+			revisions = append(revisions, key)
+		}
+	}
+	require.Equal(t, []string{
+		"myproject/ReplicationController/nodejs-1",
+		"myproject/ReplicationController/nodejs-2",
+		"myproject/ReplicationController/nodejs-10",
+	}, revisions)
+}
+
+func TestAppServer_GetTopologySnapshotNamespaces(t *testing.T) {
+	var objects []runtime.Object
+	for i, namespace := range []string{"dev", "stage", "prod"} {
+		labels := map[string]string{
+			"app.kubernetes.io/name":    "nodejs",
+			"app.kubernetes.io/part-of": "testapp",
+		}
+		objects = append(objects,
+			&deploymentconfigv1.DeploymentConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: namespace, UID: types.UID(fmt.Sprint(i)), Labels: labels},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "nodejs-" + namespace, Namespace: namespace, Labels: labels},
+			},
+		)
+	}
+	k := test.FakeKubeClient(objects...)
+
+	for _, namespaces := range [][]string{{"dev", "stage"}, {metav1.NamespaceAll}} {
+		snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, namespaces...)
+		require.NoError(t, err)
+
+		// Nodes of the same name stay apart and only get the resources of
+		// their own namespace.
+		seen := make(map[string]bool)
+		for _, node := range snapshot.Graph.Nodes {
+			require.Equal(t, "nodejs", node.Name)
+			require.False(t, seen[node.ID])
+			seen[node.ID] = true
+			nodeData := snapshot.Topology[topology.NodeID(node.ID)]
+			require.Equal(t, node.Namespace, nodeData.Namespace)
+			require.Len(t, nodeData.Resources, 2)
+			require.Equal(t, "nodejs-"+node.Namespace, nodeData.Resources[1].Name)
+		}
+
+		// The application is one group over all namespaces.
+		require.Len(t, snapshot.Graph.Groups, 1)
+		if namespaces[0] == metav1.NamespaceAll {
+			require.Len(t, snapshot.Graph.Nodes, 3)
+			require.Len(t, snapshot.Graph.Groups[0].Nodes, 3)
+		} else {
+			require.Len(t, snapshot.Graph.Nodes, 2)
+			require.Len(t, snapshot.Graph.Groups[0].Nodes, 2)
+		}
+	}
+}
+
+func TestAppServer_GetTopologySnapshotFilter(t *testing.T) {
+	var objects []runtime.Object
+	for i, app := range []string{"testapp", "otherapp"} {
+		// Only the nodes are part of the application. Their resources
+		// merely share the name label or are controlled by them.
+		name := map[string]string{"app.kubernetes.io/name": app + "-nodejs"}
+		labels := map[string]string{
+			"app.kubernetes.io/name":    app + "-nodejs",
+			"app.kubernetes.io/part-of": app,
+		}
+		dc := &deploymentconfigv1.DeploymentConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: app + "-nodejs", Namespace: "myproject", UID: types.UID(fmt.Sprint(i, "-dc")), Labels: labels},
+		}
+		rc := &corev1.ReplicationController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            app + "-nodejs-1",
+				Namespace:       "myproject",
+				UID:             types.UID(fmt.Sprint(i, "-rc")),
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dc, deploymentconfigv1.GroupVersion.WithKind("DeploymentConfig"))},
+			},
+		}
+		objects = append(objects, dc, rc,
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            app + "-nodejs-1-a",
+					Namespace:       "myproject",
+					UID:             types.UID(fmt.Sprint(i, "-pod")),
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rc, corev1.SchemeGroupVersion.WithKind("ReplicationController"))},
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: app + "-nodejs", Namespace: "myproject", Labels: name},
+			},
+			&routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{Name: app + "-nodejs", Namespace: "myproject", Labels: name},
+			},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      app + "-worker",
+					Namespace: "myproject",
+					UID:       types.UID(fmt.Sprint(i, "-deployment")),
+					Labels:    map[string]string{"app.kubernetes.io/part-of": app},
+				},
+			},
+		)
+	}
+	k := test.FakeKubeClient(objects...)
+
+	nodeNames := func(snapshot topology.VisualizationResponse) []string {
+		var names []string
+		for _, node := range snapshot.Graph.Nodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+	resourceKinds := func(nodeData topology.NodeData) []string {
+		var kinds []string
+		for _, r := range nodeData.Resources {
+			kinds = append(kinds, r.Kind)
+		}
+		return kinds
+	}
+	dcKinds := []string{"DeploymentConfig", "Pod", "Service", "Route"}
+
+	t.Run("part of", func(t *testing.T) {
+		filter := kubeclient.Filter{LabelSelector: "app.kubernetes.io/part-of=testapp"}
+		snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), filter, "myproject")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"testapp-nodejs", "testapp-worker"}, nodeNames(snapshot))
+		require.ElementsMatch(t, dcKinds, resourceKinds(snapshot.Topology["myproject/DeploymentConfig/testapp-nodejs"]))
+	})
+
+	t.Run("kinds", func(t *testing.T) {
+		filter := kubeclient.Filter{Kinds: []string{"DeploymentConfig"}}
+		snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), filter, "myproject")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"testapp-nodejs", "otherapp-nodejs"}, nodeNames(snapshot))
+		for _, nodeData := range snapshot.Topology {
+			require.ElementsMatch(t, dcKinds, resourceKinds(nodeData))
+		}
+	})
+
+	t.Run("field selector", func(t *testing.T) {
+		filter := kubeclient.Filter{FieldSelector: "metadata.name=testapp-worker"}
+		snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), filter, "myproject")
+		require.NoError(t, err)
+		require.Equal(t, []string{"testapp-worker"}, nodeNames(snapshot))
+	})
+}
+
+func TestAppServer_GetTopologySnapshotNodeIDs(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "2"},
+	}
+	k := test.FakeKubeClient(dc, deployment)
+
+	// Objects of different kinds may share their name. Their IDs do not
+	// depend on their UIDs, so they stay the same when they are recreated.
+	for i := 0; i < 2; i++ {
+		snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+		require.NoError(t, err)
+		var ids []string
+		for _, node := range snapshot.Graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		require.ElementsMatch(t, []string{"myproject/DeploymentConfig/nodejs", "myproject/Deployment/nodejs"}, ids)
+
+		require.NoError(t, k.CoreClient.AppsV1().Deployments("myproject").Delete("nodejs", nil))
+		deployment.UID = "3"
+		_, err = k.CoreClient.AppsV1().Deployments("myproject").Create(deployment)
+		require.NoError(t, err)
+	}
+}
+
+func TestAppServer_GetTopologySnapshotEmptyNamespace(t *testing.T) {
+	k := test.FakeKubeClient()
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Empty(t, snapshot.Graph.Nodes)
+	require.Empty(t, snapshot.Topology)
+}
+
+func TestAppServer_GetTopologySnapshotRegisteredKind(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}
+	cluster := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "db",
+			"namespace": "myproject",
+			"uid":       "1",
+			"labels":    map[string]interface{}{"app.kubernetes.io/name": "db"},
+		},
+		"spec":   map[string]interface{}{"version": "3.2.13"},
+		"status": map[string]interface{}{"size": int64(3)},
+	}}
+	cluster.SetGroupVersionKind(gvk)
+	kinds := kubeclient.NewDefaultKindRegistry()
+	require.NoError(t, kinds.Register(kubeclient.NewDynamicKind("EtcdCluster", gvk, true, "operator-backed")))
+
+	snapshot, err := getTopologySnapshot(kinds, test.FakeKubeClient(cluster), defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	require.Len(t, snapshot.Graph.Nodes, 1)
+	require.Equal(t, "db", snapshot.Graph.Nodes[0].Name)
+	nodeData := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)]
+	require.Equal(t, "operator-backed", nodeData.Type)
+	require.Len(t, nodeData.Resources, 1)
+	require.Equal(t, "EtcdCluster", nodeData.Resources[0].Kind)
+	require.JSONEq(t, `{"size":3}`, string(nodeData.Resources[0].Status))
+	require.Contains(t, string(nodeData.Resources[0].Metadata), `"name":"db"`)
+	require.NotContains(t, string(nodeData.Resources[0].Metadata), "size")
+	require.Nil(t, nodeData.Resources[0].Spec)
+
+	// The spec is only sent when asked for.
+	view := topologyView{fields: resourceFields{spec: true}}
+	snapshot, err = getTopologySnapshot(kinds, test.FakeKubeClient(cluster), view, kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	resource := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Resources[0]
+	require.JSONEq(t, `{"version":"3.2.13"}`, string(resource.Spec))
+	require.Nil(t, resource.Metadata)
+	require.Nil(t, resource.Status)
+}
+
+func TestAppServer_GetTopologySnapshotPods(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "nodejs-5d4f8",
+			Namespace:       "myproject",
+			UID:             "2",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+	}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ruby", Namespace: "myproject", UID: "3"},
+	}
+	rc := &corev1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "ruby-1",
+			Namespace:       "myproject",
+			UID:             "4",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dc, deploymentconfigv1.GroupVersion.WithKind("DeploymentConfig"))},
+		},
+	}
+	pod := func(name string, owner metav1.Object, kind string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "myproject",
+				UID:             types.UID(name),
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, corev1.SchemeGroupVersion.WithKind(kind))},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	k := test.FakeKubeClient(deployment, replicaSet, dc, rc,
+		pod("nodejs-5d4f8-a", replicaSet, "ReplicaSet", corev1.PodRunning),
+		pod("nodejs-5d4f8-b", replicaSet, "ReplicaSet", corev1.PodPending),
+		pod("ruby-1-a", rc, "ReplicationController", corev1.PodFailed),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "myproject", Labels: labels}},
+	)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 2)
+
+	podNames := func(nodeData topology.NodeData) []string {
+		var names []string
+		for _, r := range nodeData.Resources {
+			if r.Kind == "Pod" {
+				names = append(names, r.Name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+	for _, node := range snapshot.Graph.Nodes {
+		nodeData := snapshot.Topology[topology.NodeID(node.ID)]
+		switch node.Name {
+		case "nodejs":
+			// Pods are linked through their owners rather than their labels.
+			require.Equal(t, []string{"nodejs-5d4f8-a", "nodejs-5d4f8-b"}, podNames(nodeData))
+			require.Equal(t, "1", nodeData.Data.DonutStatus["Running"])
+			require.Equal(t, "1", nodeData.Data.DonutStatus["Pending"])
+			require.Equal(t, "0", nodeData.Data.DonutStatus["Failed"])
+		case "ruby":
+			require.Equal(t, []string{"ruby-1-a"}, podNames(nodeData))
+			require.Equal(t, "0", nodeData.Data.DonutStatus["Running"])
+			require.Equal(t, "1", nodeData.Data.DonutStatus["Failed"])
+		default:
+			t.Fatalf("unexpected node %q", node.Name)
+		}
+	}
+}
+
+func TestAppServer_GetTopologySnapshotWorkloadKinds(t *testing.T) {
+	knative := func(kind string, name string, uid string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("serving.knative.dev/v1alpha1")
+		obj.SetKind(kind)
+		obj.SetName(name)
+		obj.SetNamespace("myproject")
+		obj.SetUID(types.UID(uid))
+		return obj
+	}
+	service := knative("Service", "greeter", "1")
+	revision := knative("Revision", "greeter-00001", "2")
+	revision.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "serving.knative.dev/v1alpha1", Kind: "Configuration", Name: "greeter", UID: "3", Controller: &[]bool{true}[0],
+	}})
+	revisionDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "greeter-00001-deployment",
+			Namespace:       "myproject",
+			UID:             "4",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(revision, schema.GroupVersionKind{Group: "serving.knative.dev", Version: "v1alpha1", Kind: "Revision"})},
+		},
+	}
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "myproject", UID: "5"},
+	}
+	cronJobJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "backup-1554120000",
+			Namespace:       "myproject",
+			UID:             "6",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1beta1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+	}
+	cronJobPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "backup-1554120000-abcde",
+			Namespace:       "myproject",
+			UID:             "7",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJobJob, batchv1.SchemeGroupVersion.WithKind("Job"))},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "myproject", UID: "8"}}
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "myproject", UID: "9"}}
+	daemonSet := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "myproject", UID: "10"}}
+	k := test.FakeKubeClient(service, revision, revisionDeployment, cronJob, cronJobJob, cronJobPod, job, statefulSet, daemonSet)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	nodeTypes := make(map[string]string)
+	resources := make(map[string][]string)
+	for _, node := range snapshot.Graph.Nodes {
+		nodeData := snapshot.Topology[topology.NodeID(node.ID)]
+		nodeTypes[node.Name] = nodeData.Type
+		for _, r := range nodeData.Resources {
+			resources[node.Name] = append(resources[node.Name], r.Kind+"/"+r.Name)
+		}
+		if node.Name == "backup" {
+			require.Equal(t, "1", nodeData.Data.DonutStatus["Succeeded"])
+		}
+	}
+	require.Equal(t, map[string]string{
+		"greeter":       "knative-service",
+		"greeter-00001": "knative-revision",
+		"backup":        "workload",
+		"migrate":       "workload",
+		"db":            "workload",
+		"agent":         "workload",
+	}, nodeTypes)
+	// Workloads that are controlled by a node are part of that node.
+	require.ElementsMatch(t, []string{"Revision/greeter-00001", "Deployment/greeter-00001-deployment"}, resources["greeter-00001"])
+	require.ElementsMatch(t, []string{"CronJob/backup", "Job/backup-1554120000", "Pod/backup-1554120000-abcde"}, resources["backup"])
+}
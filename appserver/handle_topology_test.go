@@ -1,42 +1,68 @@
 package appserver
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/configuration"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/redhat-developer/app-service/testutils"
 	"github.com/stretchr/testify/require"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 func TestAppServer_GetResources(t *testing.T) {
-	var nMap nodesMap
-	var iData innerData
+	var entry topology.StoreEntry
 	labels := make(map[string]string)
 	labels["app.kubernetes.io/name"] = "testapp"
 
-	iData.nd.ID = "1"
-	iData.nd.Type = "workload"
-	iData.nd.Data.BuilderImage = "test"
-	iData.nd.Data.DonutStatus = make(map[string]string)
-	iData.nd.Data.EditURL = "https://test/url"
-	iData.nd.Data.URL = "https://test/url"
-	iData.nd.Name = "testapp"
-	iData.nm.ID = "1"
-	iData.nm.Labels = labels
-	iData.nm.Name = "testapp"
-	iData.nm.Type = "workload"
-	iData.nm.Kind = "Service"
-	iData.nm.Value = make(map[string]string)
-
-	expected := make(map[string]string)
-	expected["1"] = "{\"name\":\"testapp\",\"type\":\"workload\",\"id\":\"1\",\"data\":{\"url\":\"https://test/url\",\"editUrl\":\"https://test/url\",\"builderImage\":\"test\",\"donutStatus\":{}}}"
-
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["testing"] = iData
-
-	resources := nMap.getResources()
+	entry.Data.ID = "1"
+	entry.Data.Type = "workload"
+	entry.Data.Data.BuilderImage = "test"
+	entry.Data.Data.DonutStatus = make(map[string]string)
+	entry.Data.Data.EditURL = "https://test/url"
+	entry.Data.Data.URL = "https://test/url"
+	entry.Data.Name = "testapp"
+	entry.Meta.ID = "1"
+	entry.Meta.Labels = labels
+	entry.Meta.Name = "testapp"
+	entry.Meta.Type = "workload"
+	entry.Meta.Kind = "Service"
+	entry.Meta.Value = make(map[string]string)
+	entry.Data.Resources = []topology.Resource{{
+		Name:     "testapp",
+		Kind:     "Service",
+		Metadata: json.RawMessage(`{"name":"testapp"}`),
+		Status:   json.RawMessage("{}"),
+		Spec:     json.RawMessage(`{"ports":[]}`),
+	}}
+
+	snapshot := topology.Snapshot{"testing": entry}
+
+	resources := getResources(snapshot, defaultResourceFields)
+	require.Equal(t, json.RawMessage(`{"name":"testapp"}`), resources["1"].Resources[0].Metadata)
+	require.Nil(t, resources["1"].Resources[0].Spec)
 	require.Equal(t, "1", resources["1"].ID)
 	require.Equal(t, "testapp", resources["1"].Name)
 	require.Equal(t, "workload", resources["1"].Type)
@@ -46,78 +72,110 @@ func TestAppServer_GetResources(t *testing.T) {
 	require.Equal(t, "https://test/url", resources["1"].Data.URL)
 }
 
+func TestFieldsParam(t *testing.T) {
+	tests := map[string]resourceFields{
+		"":                                      defaultResourceFields,
+		"fields=spec":                           {spec: true},
+		"fields=metadata,status&fields=summary": {metadata: true, status: true, summary: true},
+	}
+	for query, expected := range tests {
+		fields, err := fieldsParam(httptest.NewRequest(http.MethodGet, "/topology?"+query, nil))
+		require.NoError(t, err, query)
+		require.Equal(t, expected, fields, query)
+	}
+
+	_, err := fieldsParam(httptest.NewRequest(http.MethodGet, "/topology?fields=metadata,labels", nil))
+	require.Error(t, err)
+}
+
 func TestAppServer_GetEdges(t *testing.T) {
-	var nMap nodesMap
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
 	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
 
-	t.Log(nginx.nm.Annotations)
+	t.Log(nginx.Meta.Annotations)
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-	nMap.nodes["nodejs"] = nodejs
+	snapshot := topology.Snapshot{"nginx": nginx, "nodejs": nodejs}
 
-	edges := nMap.getEdges()
-	require.Equal(t, "2", edges[0].ID)
+	edges := getEdges(snapshot)
+	require.Equal(t, "2->1:connects-to", edges[0].ID)
 	require.Equal(t, "2", edges[0].Source)
 	require.Equal(t, "1", edges[0].Target)
 	require.Equal(t, "connects-to", edges[0].Type)
 
+	// Edges from the same source have their own IDs.
+	perl := createResource("3", "DeploymentConfig", "perl", "testapp", "nodejs")
+	snapshot["perl"] = perl
+	edges = getEdges(snapshot)
+	require.Len(t, edges, 2)
+	require.NotEqual(t, edges[0].ID, edges[1].ID)
 }
 
 func TestAppServer_GetGroups(t *testing.T) {
-	var nMap nodesMap
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
+	snapshot := topology.Snapshot{"nginx": nginx}
 
-	groups := nMap.getGroups()
+	groups := getGroups(snapshot, configuration.DefaultTopologyGroupLabels)
 
 	require.Equal(t, "testapp", groups[0].Name)
 	require.Equal(t, "group:testapp", groups[0].ID)
 }
 
+func TestAppServer_GetGroupsNested(t *testing.T) {
+	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "")
+	nginx.Meta.Labels["app.kubernetes.io/component"] = "frontend"
+	nginx.Meta.Labels["app.kubernetes.io/instance"] = "nginx-1"
+	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
+	nodejs.Meta.Labels["app.kubernetes.io/component"] = "frontend"
+	perl := createResource("3", "DeploymentConfig", "perl", "otherapp", "")
+	// Instances are only grouped below their component.
+	perl.Meta.Labels["app.kubernetes.io/instance"] = "perl-1"
+	ruby := createResource("4", "DeploymentConfig", "ruby", "", "")
+	snapshot := topology.Snapshot{"nginx": nginx, "nodejs": nodejs, "perl": perl, "ruby": ruby}
+
+	groups := getGroups(snapshot, configuration.DefaultTopologyGroupLabels)
+
+	require.Equal(t, []topology.Group{
+		{ID: "group:testapp", Name: "testapp", Nodes: []string{"1", "2"}, Groups: []string{"group:testapp/frontend"}},
+		{ID: "group:testapp/frontend", Name: "frontend", Nodes: []string{"1", "2"}, ParentID: "group:testapp", Groups: []string{"group:testapp/frontend/nginx-1"}},
+		{ID: "group:testapp/frontend/nginx-1", Name: "nginx-1", Nodes: []string{"1"}, ParentID: "group:testapp/frontend"},
+		{ID: "group:otherapp", Name: "otherapp", Nodes: []string{"3"}},
+	}, groups)
+
+	// Groups can be made of other labels.
+	groups = getGroups(snapshot, []string{"app.kubernetes.io/name"})
+	require.Len(t, groups, 4)
+}
+
 func TestAppServer_GetNode(t *testing.T) {
-	var nMap nodesMap
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
+	snapshot := topology.Snapshot{"nginx": nginx}
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-
-	nodes := nMap.getNode()
+	nodes := getNode(snapshot)
 
 	require.Equal(t, "1", nodes[0].ID)
 	require.Equal(t, "nginx", nodes[0].Name)
 }
 
 func TestAppServer_GetLabelData(t *testing.T) {
-	var nMap nodesMap
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
 	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
+	snapshot := topology.Snapshot{"nginx": nginx, "nodejs": nodejs}
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-	nMap.nodes["nodejs"] = nodejs
-
-	labelData := nMap.getLabelData("app.kubernetes.io/name", "")
+	labelData := getLabelData(snapshot, "app.kubernetes.io/name", "")
 
-	expected := make(map[string][]nodeMeta)
-	expected["nginx"] = append(expected["nginx"], nginx.nm)
-	expected["nodejs"] = append(expected["nodejs"], nodejs.nm)
+	expected := make(map[string][]topology.NodeMeta)
+	expected["nginx"] = append(expected["nginx"], nginx.Meta)
+	expected["nodejs"] = append(expected["nodejs"], nodejs.Meta)
 
 	require.Equal(t, expected, labelData)
 }
 
 func TestAppServer_GetAnnotationData(t *testing.T) {
-	var nMap nodesMap
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
 	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
+	snapshot := topology.Snapshot{"nginx": nginx, "nodejs": nodejs}
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-	nMap.nodes["nodejs"] = nodejs
-
-	annotationData := nMap.getAnnotationData("app.openshift.io/connects-to")
+	annotationData := getAnnotationData(snapshot, "app.openshift.io/connects-to")
 
 	expected := make(map[string][]string)
 	expected["nodejs"] = append(expected["nodejs"], "1")
@@ -125,161 +183,643 @@ func TestAppServer_GetAnnotationData(t *testing.T) {
 	require.Equal(t, expected, annotationData)
 }
 
-func TestAppServer_DeleteNode(t *testing.T) {
-	var nMap nodesMap
-	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
-	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
-
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-	nMap.nodes["nodejs"] = nodejs
-
-	nMap.deleteNode(nodejs.nm)
-
-	expected := make(map[string][]string)
-	expected["nodejs"] = append(expected["nodejs"], "1")
-
-	require.Equal(t, "", nMap.nodes["nodejs"].nm.ID)
-	require.Equal(t, "1", nMap.nodes["nginx"].nm.ID)
-}
-
 func TestAppServer_AddOrUpdateNode(t *testing.T) {
-	var nMap nodesMap
+	store := topology.NewStore()
 	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
 	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
 
 	newNodejs := createResource("3", "DeploymentConfig", "nodejs", "testapp", "")
 	perl := createResource("4", "DeploymentConfig", "perl", "testapp", "")
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nginx"] = nginx
-	nMap.nodes["nodejs"] = nodejs
+	store.Add(nginx)
+	store.Add(nodejs)
 
 	// Test updating nodejs
-	nMap.addOrUpdateNodeMeta(newNodejs.nm)
+	addOrUpdateNodeMeta(store, newNodejs.Meta)
 
-	require.Equal(t, "3", nMap.nodes["nodejs"].nm.ID)
-	require.Equal(t, "1", nMap.nodes["nginx"].nm.ID)
-	require.Equal(t, "", nMap.nodes["perl"].nm.ID)
+	snapshot := store.Snapshot()
+	require.Equal(t, "3", snapshot["DeploymentConfig/nodejs"].Meta.ID)
+	require.Equal(t, "1", snapshot["DeploymentConfig/nginx"].Meta.ID)
+	require.Equal(t, "", snapshot["DeploymentConfig/perl"].Meta.ID)
 
 	// Test adding perl
-	nMap.addOrUpdateNodeMeta(perl.nm)
+	addOrUpdateNodeMeta(store, perl.Meta)
 
-	require.Equal(t, "3", nMap.nodes["nodejs"].nm.ID)
-	require.Equal(t, "1", nMap.nodes["nginx"].nm.ID)
-	require.Equal(t, "4", nMap.nodes["perl"].nm.ID)
+	snapshot = store.Snapshot()
+	require.Equal(t, "3", snapshot["DeploymentConfig/nodejs"].Meta.ID)
+	require.Equal(t, "1", snapshot["DeploymentConfig/nginx"].Meta.ID)
+	require.Equal(t, "4", snapshot["DeploymentConfig/perl"].Meta.ID)
 }
 
 func TestAppServer_DeleteNodeResource(t *testing.T) {
-	var nMap nodesMap
+	store := topology.NewStore()
 	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
 
 	var resourceService topology.Resource
 	var resourceDeploymentConfig topology.Resource
 
-	labels := make(map[string]string)
-	labels["app.kubernetes.io/name"] = "testapp"
-
 	resourceService.Kind = "Service"
-	resourceService.Metadata = "{}"
+	resourceService.Metadata = json.RawMessage("{}")
 	resourceService.Name = "nodejs"
-	resourceService.Status = "{}"
+	resourceService.Status = json.RawMessage("{}")
 
 	resourceDeploymentConfig.Kind = "DeploymentConfig"
-	resourceDeploymentConfig.Metadata = "{}"
+	resourceDeploymentConfig.Metadata = json.RawMessage("{}")
 	resourceDeploymentConfig.Name = "nodejs"
-	resourceDeploymentConfig.Status = "{}"
-
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nodejs"] = nodejs
-	nMap.addOrUpdateNodeResource("nodejs", resourceDeploymentConfig)
-	nMap.addOrUpdateNodeResource("nodejs", resourceService)
-
-	require.Equal(t, 2, len(nMap.nodes["nodejs"].nd.Resources))
-
-	nMap.deleteNodeResource(nodejs.nm, resourceService)
-
-	require.Equal(t, 1, len(nMap.nodes["nodejs"].nd.Resources))
-	require.Equal(t, "nodejs", nMap.nodes["nodejs"].nd.Resources[0].Name)
-	require.Equal(t, "DeploymentConfig", nMap.nodes["nodejs"].nd.Resources[0].Kind)
+	resourceDeploymentConfig.Status = json.RawMessage("{}")
+
+	store.Add(nodejs)
+	kinds := kubeclient.NewDefaultKindRegistry()
+	addResourceToNode(kinds, store, nodejs.Meta, resourceDeploymentConfig)
+	addResourceToNode(kinds, store, nodejs.Meta, resourceService)
+
+	entry, _ := store.Get("DeploymentConfig/nodejs")
+	require.Equal(t, 2, len(entry.Data.Resources))
+
+	deleteNodeResource(store, nodejs.Meta, resourceService)
+
+	entry, _ = store.Get("DeploymentConfig/nodejs")
+	require.Equal(t, 1, len(entry.Data.Resources))
+	require.Equal(t, "nodejs", entry.Data.Resources[0].Name)
+	require.Equal(t, "DeploymentConfig", entry.Data.Resources[0].Kind)
+
+	// Only the deleted resource is removed from resources of the same kind.
+	metricsService := resourceService
+	metricsService.Name = "nodejs-metrics"
+	addResourceToNode(kinds, store, nodejs.Meta, resourceService)
+	addResourceToNode(kinds, store, nodejs.Meta, metricsService)
+	deleteNodeResource(store, nodejs.Meta, resourceService)
+
+	entry, _ = store.Get("DeploymentConfig/nodejs")
+	require.Equal(t, 2, len(entry.Data.Resources))
+	require.Equal(t, "nodejs-metrics", entry.Data.Resources[1].Name)
+
+	// Deleting a resource of an unknown node does not create the node.
+	deleteNodeResource(store, createResource("5", "DeploymentConfig", "perl", "testapp", "").Meta, resourceService)
+	_, ok := store.Get("DeploymentConfig/perl")
+	require.False(t, ok)
 }
 
 func TestAppServer_AddOrUpdateNodeResource(t *testing.T) {
-	var nMap nodesMap
-	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
 	var resourceDeploymentConfig topology.Resource
 	var newDeploymentConfig topology.Resource
 
-	labels := make(map[string]string)
-	labels["app.kubernetes.io/name"] = "testapp"
-
 	resourceDeploymentConfig.Kind = "DeploymentConfig"
-	resourceDeploymentConfig.Metadata = "{}"
+	resourceDeploymentConfig.Metadata = json.RawMessage("{}")
 	resourceDeploymentConfig.Name = "nodejs"
-	resourceDeploymentConfig.Status = "{}"
+	resourceDeploymentConfig.Status = json.RawMessage("{}")
 
 	newDeploymentConfig.Kind = "DeploymentConfig"
-	newDeploymentConfig.Metadata = "{\"test\": \"test\"}"
+	newDeploymentConfig.Metadata = json.RawMessage(`{"test": "test"}`)
 	newDeploymentConfig.Name = "nodejs"
-	newDeploymentConfig.Status = "{}"
+	newDeploymentConfig.Status = json.RawMessage("{}")
+
+	resources := addOrUpdateNodeResource(nil, resourceDeploymentConfig)
+
+	require.Equal(t, 1, len(resources))
+	require.Equal(t, "nodejs", resources[0].Name)
+	require.Equal(t, "DeploymentConfig", resources[0].Kind)
+	require.Equal(t, json.RawMessage("{}"), resources[0].Metadata)
+
+	resources = addOrUpdateNodeResource(resources, newDeploymentConfig)
+
+	require.Equal(t, 1, len(resources))
+	require.Equal(t, "nodejs", resources[0].Name)
+	require.Equal(t, "DeploymentConfig", resources[0].Kind)
+	require.Equal(t, json.RawMessage(`{"test": "test"}`), resources[0].Metadata)
+
+	// Resources of the same kind but another name or namespace are added.
+	otherName := newDeploymentConfig
+	otherName.Name = "perl"
+	otherNamespace := newDeploymentConfig
+	otherNamespace.Namespace = "stage"
+	resources = addOrUpdateNodeResource(resources, otherName)
+	resources = addOrUpdateNodeResource(resources, otherNamespace)
+	require.Equal(t, 3, len(resources))
+}
+
+// Gets the view of the topology that clients get by default.
+func defaultTopologyView() topologyView {
+	return topologyView{groupLabels: configuration.DefaultTopologyGroupLabels, fields: defaultResourceFields}
+}
 
-	nMap.nodes = make(map[string]innerData)
-	nMap.nodes["nodejs"] = nodejs
-	nMap.addOrUpdateNodeResource("nodejs", resourceDeploymentConfig)
+func createResource(id string, kind string, name string, partOf string, annotation string) topology.StoreEntry {
+	var entry topology.StoreEntry
 
-	require.Equal(t, 1, len(nMap.nodes["nodejs"].nd.Resources))
-	require.Equal(t, "nodejs", nMap.nodes["nodejs"].nd.Resources[0].Name)
-	require.Equal(t, "DeploymentConfig", nMap.nodes["nodejs"].nd.Resources[0].Kind)
-	require.Equal(t, "{}", nMap.nodes["nodejs"].nd.Resources[0].Metadata)
+	labels := make(map[string]string)
+	annotations := make(map[string]string)
+	labels["app.kubernetes.io/name"] = name
+	labels["app.kubernetes.io/part-of"] = partOf
 
-	nMap.addOrUpdateNodeResource("nodejs", newDeploymentConfig)
+	entry.Data.ID = id
+	entry.Data.Type = "workload"
+	entry.Data.Data.BuilderImage = "test"
+	entry.Data.Data.DonutStatus = make(map[string]string)
+	entry.Data.Data.EditURL = "https://test/url"
+	entry.Data.Data.URL = "https://test/url"
+	entry.Meta.ID = id
+	entry.Meta.Labels = labels
+	entry.Meta.Name = name
+	entry.Meta.Type = "workload"
+	entry.Meta.Kind = kind
+	entry.Meta.Value = make(map[string]string)
+	if annotation != "" {
+		annotations["app.openshift.io/connects-to"] = "[" + "\"" + annotation + "\"" + "]"
+		entry.Meta.Annotations = annotations
+	}
 
-	require.Equal(t, 1, len(nMap.nodes["nodejs"].nd.Resources))
-	require.Equal(t, "nodejs", nMap.nodes["nodejs"].nd.Resources[0].Name)
-	require.Equal(t, "DeploymentConfig", nMap.nodes["nodejs"].nd.Resources[0].Kind)
-	require.Equal(t, "{\"test\": \"test\"}", nMap.nodes["nodejs"].nd.Resources[0].Metadata)
+	return entry
 }
 
-func TestAppServer_GetResourcesListOptions(t *testing.T) {
-	nMeta := make(map[string][]nodeMeta)
-	nginx := createResource("1", "DeploymentConfig", "nginx", "testapp", "nodejs")
-	nodejs := createResource("2", "DeploymentConfig", "nodejs", "testapp", "")
+func TestAppServer_HandleEvent(t *testing.T) {
+	labels := map[string]string{
+		"app.kubernetes.io/name":    "nodejs",
+		"app.kubernetes.io/part-of": "testapp",
+	}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(test.FakeKubeClient(dc, service), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+
+	store := topology.NewStore()
+
+	// A resource without its node is ignored.
+	handleEvent(store, c, watch.Event{Type: watch.Added, Object: service})
+	require.Empty(t, store.Snapshot())
 
-	nMeta["nginx"] = append(nMeta["nginx"], nginx.nm)
-	nMeta["nodejs"] = append(nMeta["nodejs"], nodejs.nm)
+	// A new node gets its cached resources attached.
+	handleEvent(store, c, watch.Event{Type: watch.Added, Object: dc})
+	require.Len(t, store.Snapshot()["myproject/DeploymentConfig/nodejs"].Data.Resources, 2)
 
-	listOptions := getResourcesListOptions(nMeta)
-	optionsNodeJS := metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s", "nodejs"),
+	// Deleting a resource detaches it from the node.
+	handleEvent(store, c, watch.Event{Type: watch.Deleted, Object: service})
+	require.Len(t, store.Snapshot()["myproject/DeploymentConfig/nodejs"].Data.Resources, 1)
+	require.Equal(t, "DeploymentConfig", store.Snapshot()["myproject/DeploymentConfig/nodejs"].Data.Resources[0].Kind)
+
+	// Deleting the node removes it.
+	handleEvent(store, c, watch.Event{Type: watch.Deleted, Object: dc})
+	require.Empty(t, store.Snapshot())
+}
+
+func TestAppServer_HandleEventPods(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	rc := &corev1.ReplicationController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "nodejs-1",
+			Namespace:       "myproject",
+			UID:             "2",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dc, deploymentconfigv1.GroupVersion.WithKind("DeploymentConfig"))},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "nodejs-1-abcde",
+			Namespace:       "myproject",
+			UID:             "3",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rc, corev1.SchemeGroupVersion.WithKind("ReplicationController"))},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	k := test.FakeKubeClient(dc, rc)
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(k, kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+
+	store := topology.NewStore()
+	buildTopology(store, c)
+	require.Equal(t, "0", store.Snapshot()["myproject/DeploymentConfig/nodejs"].Data.Data.DonutStatus["Running"])
+
+	// Wait for the cache to see changes so that the events can be applied.
+	w := c.Watch()
+	defer w.Stop()
+	next := func() watch.Event {
+		select {
+		case event := <-w.ResultChan():
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the cache")
+			return watch.Event{}
+		}
 	}
 
-	require.Equal(t, "nodejs", listOptions[optionsNodeJS].Name)
+	_, err = k.CoreClient.CoreV1().Pods("myproject").Create(pod)
+	require.NoError(t, err)
+	handleEvent(store, c, next())
+	entry := store.Snapshot()["myproject/DeploymentConfig/nodejs"]
+	require.Equal(t, "1", entry.Data.Data.DonutStatus["Running"])
+	require.Equal(t, "Pod", entry.Data.Resources[len(entry.Data.Resources)-1].Kind)
+
+	require.NoError(t, k.CoreClient.CoreV1().Pods("myproject").Delete(pod.Name, nil))
+	handleEvent(store, c, next())
+	entry = store.Snapshot()["myproject/DeploymentConfig/nodejs"]
+	require.Equal(t, "0", entry.Data.Data.DonutStatus["Running"])
+	for _, r := range entry.Data.Resources {
+		require.NotEqual(t, "Pod", r.Kind)
+	}
 }
 
-func createResource(id string, kind string, name string, partOf string, annotation string) innerData {
-	var iData innerData
+// Events are applied while the topology is read from other goroutines, which
+// the race detector checks.
+func TestAppServer_HandleEventConcurrentReads(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(test.FakeKubeClient(dc), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+
+	store := topology.NewStore()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					getTopology(store.Snapshot(), defaultTopologyView())
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("nodejs-%d", i), Namespace: "myproject", Labels: labels},
+		}
+		handleEvent(store, c, watch.Event{Type: watch.Added, Object: dc})
+		handleEvent(store, c, watch.Event{Type: watch.Modified, Object: service})
+		handleEvent(store, c, watch.Event{Type: watch.Deleted, Object: service})
+	}
+	close(done)
+	wg.Wait()
+}
 
-	labels := make(map[string]string)
-	annotations := make(map[string]string)
-	labels["app.kubernetes.io/name"] = name
-	labels["app.kubernetes.io/part-of"] = partOf
+// Changes of shared objects only refresh the nodes of their namespace that
+// refer to them.
+func TestAppServer_ReferringNodes(t *testing.T) {
+	dc := func(name string, namespace string) *deploymentconfigv1.DeploymentConfig {
+		return &deploymentconfigv1.DeploymentConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + "/" + name)},
+			Spec: deploymentconfigv1.DeploymentConfigSpec{
+				Template: &corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"deploymentconfig": name}},
+				},
+			},
+		}
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"deploymentconfig": "nodejs"}},
+	}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec: routev1.RouteSpec{
+			Host: "nodejs.example.com",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "nodejs"},
+		},
+	}
+	k := test.FakeKubeClient(dc("nodejs", "myproject"), dc("perl", "myproject"), dc("nodejs", "stage"), service, route)
+	c, err := kubeclient.ListCache(k, kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject", "stage")
+	require.NoError(t, err)
+
+	store := topology.NewStore()
+	buildTopology(store, c)
+	require.Len(t, store.Snapshot(), 3)
+	nodejs := []string{"myproject/DeploymentConfig/nodejs"}
+
+	// The node in the other namespace has pods of the same labels.
+	require.Equal(t, nodejs, referringNodes(store, c, service))
+	require.Equal(t, nodejs, referringNodes(store, c, route))
+
+	// A route that no longer leads to the node still refreshes it once.
+	moved := route.DeepCopy()
+	moved.Spec.To.Name = "perl"
+	require.Equal(t, nodejs, referringNodes(store, c, moved))
+
+	// A new service refreshes the nodes it selects.
+	perl := service.DeepCopy()
+	perl.Name = "perl"
+	perl.Spec.Selector = map[string]string{"deploymentconfig": "perl"}
+	require.Equal(t, []string{"myproject/DeploymentConfig/perl"}, referringNodes(store, c, perl))
+
+	unrelated := route.DeepCopy()
+	unrelated.Name = "other"
+	unrelated.Spec.To.Name = "other"
+	require.Empty(t, referringNodes(store, c, unrelated))
+}
 
-	iData.nd.ID = id
-	iData.nd.Type = "workload"
-	iData.nd.Data.BuilderImage = "test"
-	iData.nd.Data.DonutStatus = make(map[string]string)
-	iData.nd.Data.EditURL = "https://test/url"
-	iData.nd.Data.URL = "https://test/url"
-	iData.nm.ID = id
-	iData.nm.Labels = labels
-	iData.nm.Name = name
-	iData.nm.Type = "workload"
-	iData.nm.Kind = kind
-	iData.nm.Value = make(map[string]string)
-	if annotation != "" {
-		annotations["app.openshift.io/connects-to"] = "[" + "\"" + annotation + "\"" + "]"
-		iData.nm.Annotations = annotations
+func TestAppServer_BadRequest(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	paths := []string{
+		"/topology",
+		"/topology?namespace=My_Project",
+		"/topology?namespace=myproject&stream=1&seq=abc",
+		"/topology?namespace=myproject",
+		"/topology/snapshot",
+		"/topology/snapshot?namespace=" + strings.Repeat("a", 64),
+		"/topology/snapshot?namespace=",
+		"/topology/snapshot?namespace=myproject&namespace=My_Project",
+		"/topology/snapshot?namespace=myproject&allNamespaces=true",
+		"/topology/snapshot?allNamespaces=maybe",
+		"/topology/snapshot?allNamespaces=false",
+		"/topology?namespace=myproject&labelSelector=app%3D%3D%3D",
+		"/topology/snapshot?namespace=myproject&fieldSelector=metadata.name",
+		"/topology/snapshot?namespace=myproject&partOf=" + strings.Repeat("a", 64),
+		"/topology/snapshot?namespace=myproject&kinds=DeploymentConfig,Unknown",
+		"/topology/snapshot?namespace=myproject&kinds=Service",
+		"/topology?namespace=myproject&fieldSelector=status.phase%3DRunning",
+		"/topology/snapshot?namespace=myproject&kinds=Deployment&fieldSelector=status.successful%3D1",
+		"/topology/snapshot?namespace=myproject&fields=spec,labels",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			r.Header.Set("Authorization", "Bearer token")
+			rr := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rr, r)
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestNamespacesParam(t *testing.T) {
+	tests := []struct {
+		query      string
+		namespaces []string
+	}{
+		{"namespace=myproject", []string{"myproject"}},
+		{"namespace=dev&namespace=stage", []string{"dev", "stage"}},
+		{"namespace=dev&allNamespaces=false", []string{"dev"}},
+		{"allNamespaces=true", []string{metav1.NamespaceAll}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			namespaces, err := namespacesParam(httptest.NewRequest(http.MethodGet, "/topology?"+tt.query, nil))
+			require.NoError(t, err)
+			require.Equal(t, tt.namespaces, namespaces)
+		})
+	}
+}
+
+func TestFilterParam(t *testing.T) {
+	tests := []struct {
+		query  string
+		filter kubeclient.Filter
+	}{
+		{"", kubeclient.Filter{}},
+		{
+			"labelSelector=tier%20in%20(web),app&partOf=testapp",
+			kubeclient.Filter{LabelSelector: "app,app.kubernetes.io/part-of=testapp,tier in (web)"},
+		},
+		{"fieldSelector=metadata.name%3Dnodejs", kubeclient.Filter{FieldSelector: "metadata.name=nodejs"}},
+		{
+			"kinds=Deployment,DeploymentConfig&kinds=Deployment",
+			kubeclient.Filter{Kinds: []string{"Deployment", "DeploymentConfig"}},
+		},
+		{
+			"kinds=Job&fieldSelector=status.successful%3D1",
+			kubeclient.Filter{FieldSelector: "status.successful=1", Kinds: []string{"Job"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			filter, err := filterParam(httptest.NewRequest(http.MethodGet, "/topology?"+tt.query, nil), kubeclient.NewDefaultKindRegistry())
+			require.NoError(t, err)
+			require.Equal(t, tt.filter, filter)
+		})
+	}
+}
+
+func TestAppServer_RequireNamespaces(t *testing.T) {
+	srv, err := New("")
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodGet, "/topology?allNamespaces=true", nil)
+
+	t.Run("allowed", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		namespaces, ok := srv.requireNamespaces(rr, r, test.FakeKubeClient())
+		require.True(t, ok)
+		require.Equal(t, []string{metav1.NamespaceAll}, namespaces)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		k := test.FakeKubeClient()
+		k.CoreClient.(*fake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", test.AllowAccessReviews(false))
+		rr := httptest.NewRecorder()
+		_, ok := srv.requireNamespaces(rr, r, k)
+		require.False(t, ok)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+func TestAppServer_RequireCacheClient(t *testing.T) {
+	t.Run("token mode", func(t *testing.T) {
+		srv, err := New("")
+		require.NoError(t, err)
+		k := test.FakeKubeClient()
+		var filter kubeclient.Filter
+		cacheClient, ok := srv.requireCacheClient(httptest.NewRecorder(), k, []string{"myproject"}, &filter)
+		require.True(t, ok)
+		require.True(t, k == cacheClient)
+	})
+
+	srv, restore := newKubeconfigAppServer(t)
+	defer restore()
+
+	t.Run("shared", func(t *testing.T) {
+		k := test.FakeKubeClient()
+		var filter kubeclient.Filter
+		cacheClient, ok := srv.requireCacheClient(httptest.NewRecorder(), k, []string{"myproject"}, &filter)
+		require.True(t, ok)
+		require.NotEqual(t, k.Identity(), cacheClient.Identity())
+		require.Empty(t, filter.ExcludedKinds)
+
+		// All callers share the client of the service.
+		other, ok := srv.requireCacheClient(httptest.NewRecorder(), test.FakeKubeClient(), []string{"myproject"}, &filter)
+		require.True(t, ok)
+		require.Equal(t, cacheClient.Identity(), other.Identity())
+	})
+
+	t.Run("denied optional kinds", func(t *testing.T) {
+		k := test.FakeKubeClient()
+		k.CoreClient.(*fake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, k8sruntime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+			review.Status.Allowed = review.Spec.ResourceAttributes.Resource != "revisions"
+			return true, review, nil
+		})
+		var filter kubeclient.Filter
+		_, ok := srv.requireCacheClient(httptest.NewRecorder(), k, []string{"dev", "stage"}, &filter)
+		require.True(t, ok)
+		require.Equal(t, []string{"Revision"}, filter.ExcludedKinds)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		k := test.FakeKubeClient()
+		k.CoreClient.(*fake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", test.AllowAccessReviews(false))
+		rr := httptest.NewRecorder()
+		var filter kubeclient.Filter
+		_, ok := srv.requireCacheClient(rr, k, []string{"myproject"}, &filter)
+		require.False(t, ok)
+		require.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
+
+func TestAppServer_CheckOrigin(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "HTTP_ALLOWED_ORIGINS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+	os.Setenv(key, "https://console.example.com/")
+	srv, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, srv.SetupRoutes())
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://app.example.com", true},
+		{"https://console.example.com", true},
+		{"https://evil.example.com", false},
+		{"https://console.example.com.evil.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://app.example.com/topology", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			require.Equal(t, tt.allowed, srv.checkOrigin(r))
+		})
+	}
+
+	// Pages of other origins cannot open a web socket with the cookie of
+	// the caller.
+	r := httptest.NewRequest(http.MethodGet, "http://app.example.com/topology?namespace=myproject", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	r.AddCookie(&http.Cookie{Name: accessTokenParam, Value: "token"})
+	rr := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rr, r)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCloseWebSocket(t *testing.T) {
+	reason := strings.Repeat("x", 200)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := convertHTTPToWebSocket(&upgrader, w, r)
+		if err != nil {
+			return
+		}
+		closeWebSocket(ws, websocket.CloseInternalServerErr, reason)
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	_, _, err = ws.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	require.True(t, ok, "expected a close error but got %v", err)
+	require.Equal(t, websocket.CloseInternalServerErr, closeErr.Code)
+	require.Equal(t, reason[:maxCloseReasonLength], closeErr.Text)
+}
+
+func TestCreateTopology_ClientLeaves(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Labels: labels},
+	}
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(test.FakeKubeClient(dc), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+	streams := newStreamRegistry()
+
+	sessionDone := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(sessionDone)
+		ws, err := convertHTTPToWebSocket(&upgrader, w, r)
+		if err != nil {
+			return
+		}
+		createTopology(r.Context(), ws, c, defaultTopologyView(), streams, "owner", "", "")
+	}))
+	defer s.Close()
+
+	// Let the goroutines of the server and the cache settle.
+	time.Sleep(100 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	var msg topology.StreamMessage
+	require.NoError(t, ws.ReadJSON(&msg))
+	require.Equal(t, topology.MessageTypeFull, msg.Type)
+	require.Len(t, msg.Full.Nodes, 1)
+
+	// The session ends once the client closes the connection.
+	require.NoError(t, ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+	ws.Close()
+	select {
+	case <-sessionDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end after the client left")
 	}
 
-	return iData
+	// The stream can be resumed and no goroutine is left behind.
+	_, ok := streams.resume(msg.Stream, "owner", c.Scope())
+	require.True(t, ok)
+	deadline := time.Now().Add(10 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, runtime.NumGoroutine() <= before, "%d goroutines left behind", runtime.NumGoroutine()-before)
+}
+
+func TestCreateTopology_CacheWatchEnds(t *testing.T) {
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(test.FakeKubeClient(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := convertHTTPToWebSocket(&upgrader, w, r)
+		if err != nil {
+			return
+		}
+		createTopology(r.Context(), ws, c, defaultTopologyView(), newStreamRegistry(), "owner", "", "")
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	var msg topology.StreamMessage
+	require.NoError(t, ws.ReadJSON(&msg))
+
+	// The client is told to resume the stream once the watch of the cache
+	// ends.
+	registry.Release(c)
+	_, _, err = ws.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	require.True(t, ok, "expected a close error but got %v", err)
+	require.Equal(t, websocket.CloseTryAgainLater, closeErr.Code)
 }
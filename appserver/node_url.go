@@ -0,0 +1,150 @@
+package appserver
+
+import (
+	"sort"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// vcsURIAnnotation is the annotation of a node that links to its source code.
+const vcsURIAnnotation = "app.openshift.io/vcs-uri"
+
+// Gets the URLs under which the node is exposed: the hosts of the routes and
+// ingresses of the services whose selector matches the pods of the node.
+// Routes come first and each group is sorted. The routes and ingresses that
+// expose the node are added to the references.
+func getNodeURLs(c *kubeclient.Cache, node runtime.Object, references objectReferences) []string {
+	services := getNodeServices(c, node)
+	if len(services) == 0 {
+		return nil
+	}
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return nil
+	}
+
+	var routeURLs []string
+	for _, obj := range c.ListKindInNamespace("Route", o.GetNamespace()) {
+		route, ok := obj.(*routev1.Route)
+		if !ok || route.Spec.Host == "" || !routesTo(route, services) {
+			continue
+		}
+		references.add(route.Namespace, "Route", route.Name)
+		routeURLs = append(routeURLs, formatURL(route.Spec.TLS != nil, route.Spec.Host, route.Spec.Path))
+	}
+
+	var ingressURLs []string
+	for _, obj := range c.ListKindInNamespace("Ingress", o.GetNamespace()) {
+		ingress, ok := obj.(*extensionsv1beta1.Ingress)
+		if !ok {
+			continue
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" || rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if services[path.Backend.ServiceName] {
+					references.add(ingress.Namespace, "Ingress", ingress.Name)
+					ingressURLs = append(ingressURLs, formatURL(hasIngressTLS(ingress, rule.Host), rule.Host, path.Path))
+				}
+			}
+		}
+	}
+
+	sort.Strings(routeURLs)
+	sort.Strings(ingressURLs)
+	return dedupeStrings(append(routeURLs, ingressURLs...))
+}
+
+// Gets the names of the cached services of the namespace of the node that
+// select its pods.
+func getNodeServices(c *kubeclient.Cache, node runtime.Object) map[string]bool {
+	kind, ok := c.Kinds().KindOf(node)
+	if !ok || kind.PodTemplate == nil {
+		return nil
+	}
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return nil
+	}
+	template := kind.PodTemplate(node)
+	if template == nil || len(template.Labels) == 0 {
+		return nil
+	}
+	services := make(map[string]bool)
+	for _, obj := range c.ListKindInNamespace("Service", o.GetNamespace()) {
+		if service, ok := obj.(*corev1.Service); ok && selectsPods(service, template) {
+			services[service.Name] = true
+		}
+	}
+	return services
+}
+
+// Checks whether the selector of the service matches the pods of the
+// template. A service without a selector selects nothing.
+func selectsPods(service *corev1.Service, template *corev1.PodTemplateSpec) bool {
+	if len(service.Spec.Selector) == 0 || template == nil || len(template.Labels) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(template.Labels))
+}
+
+// Checks whether the route leads to one of the services.
+func routesTo(route *routev1.Route, services map[string]bool) bool {
+	return route.Spec.To.Kind == "Service" && services[route.Spec.To.Name]
+}
+
+// Checks whether a path of the ingress leads to one of the services.
+func hasIngressBackend(ingress *extensionsv1beta1.Ingress, services map[string]bool) bool {
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if services[path.Backend.ServiceName] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Checks whether the ingress terminates TLS for the host.
+func hasIngressTLS(ingress *extensionsv1beta1.Ingress, host string) bool {
+	for _, tls := range ingress.Spec.TLS {
+		for _, h := range tls.Hosts {
+			if h == host {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatURL(secure bool, host string, path string) string {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path
+}
+
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package appserver
+
+import (
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppServer_NodeURLs(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nodejs",
+			Namespace:   "myproject",
+			UID:         "1",
+			Annotations: map[string]string{"app.openshift.io/vcs-uri": "https://github.com/sclorg/nodejs-ex"},
+		},
+		Spec: deploymentconfigv1.DeploymentConfigSpec{
+			Template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"deploymentconfig": "nodejs", "tier": "web"}},
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"deploymentconfig": "nodejs"}},
+	}
+	other := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myproject"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"deploymentconfig": "other"}},
+	}
+	secureRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec: routev1.RouteSpec{
+			Host: "nodejs.example.com",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "nodejs"},
+			TLS:  &routev1.TLSConfig{Termination: routev1.TLSTerminationEdge},
+		},
+	}
+	plainRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs-api", Namespace: "myproject"},
+		Spec: routev1.RouteSpec{
+			Host: "api.example.com",
+			Path: "/v1",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "nodejs"},
+		},
+	}
+	otherRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myproject"},
+		Spec: routev1.RouteSpec{
+			Host: "other.example.com",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "other"},
+		},
+	}
+	ingress := &extensionsv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+		Spec: extensionsv1beta1.IngressSpec{
+			TLS: []extensionsv1beta1.IngressTLS{{Hosts: []string{"www.example.com"}}},
+			Rules: []extensionsv1beta1.IngressRule{{
+				Host: "www.example.com",
+				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+						Paths: []extensionsv1beta1.HTTPIngressPath{
+							{Path: "/", Backend: extensionsv1beta1.IngressBackend{ServiceName: "nodejs"}},
+							{Path: "/other", Backend: extensionsv1beta1.IngressBackend{ServiceName: "other"}},
+						},
+					},
+				},
+			}},
+		},
+	}
+	k := test.FakeKubeClient(dc, service, other, secureRoute, plainRoute, otherRoute, ingress)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+
+	data := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Data
+	require.Equal(t, []string{
+		"http://api.example.com/v1",
+		"https://nodejs.example.com",
+		"https://www.example.com/",
+	}, data.URLs)
+	require.Equal(t, "http://api.example.com/v1", data.URL)
+	require.Equal(t, "https://github.com/sclorg/nodejs-ex", data.EditURL)
+}
+
+func TestAppServer_NodeURLsWithoutServices(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	k := test.FakeKubeClient(dc)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+
+	data := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Data
+	require.Empty(t, data.URLs)
+	require.Empty(t, data.URL)
+	require.Empty(t, data.EditURL)
+}
@@ -0,0 +1,68 @@
+package appserver
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Statuses of the pods that are counted in the donut status of a node.
+const (
+	podStatusRunning          = "Running"
+	podStatusPending          = "Pending"
+	podStatusFailed           = "Failed"
+	podStatusSucceeded        = "Succeeded"
+	podStatusTerminating      = "Terminating"
+	podStatusCrashLoopBackOff = "CrashLoopBackOff"
+	podStatusUnknown          = "Unknown"
+)
+
+var podStatuses = []string{
+	podStatusRunning,
+	podStatusPending,
+	podStatusFailed,
+	podStatusSucceeded,
+	podStatusTerminating,
+	podStatusCrashLoopBackOff,
+	podStatusUnknown,
+}
+
+// Gets the status of a pod as shown in the donut. A pod that is being deleted
+// is terminating and a pod with a crashing container is in a crash loop
+// regardless of its phase.
+func getPodStatus(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return podStatusTerminating
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason == podStatusCrashLoopBackOff {
+			return podStatusCrashLoopBackOff
+		}
+	}
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		return podStatusRunning
+	case corev1.PodPending:
+		return podStatusPending
+	case corev1.PodFailed:
+		return podStatusFailed
+	case corev1.PodSucceeded:
+		return podStatusSucceeded
+	default:
+		return podStatusUnknown
+	}
+}
+
+// Counts the pods per status. Every status is present so that the donut does
+// not need to know which statuses exist.
+func getDonutStatus(pods []*corev1.Pod) map[string]string {
+	counts := make(map[string]int, len(podStatuses))
+	for _, pod := range pods {
+		counts[getPodStatus(pod)]++
+	}
+	donutStatus := make(map[string]string, len(podStatuses))
+	for _, status := range podStatuses {
+		donutStatus[status] = strconv.Itoa(counts[status])
+	}
+	return donutStatus
+}
@@ -0,0 +1,57 @@
+package appserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPodStatus(t *testing.T) {
+	now := metav1.Now()
+	crashing := corev1.ContainerStatus{
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected string
+	}{
+		{"running", &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}, "Running"},
+		{"pending", &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}, "Pending"},
+		{"failed", &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed}}, "Failed"},
+		{"succeeded", &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}, "Succeeded"},
+		{"unknown", &corev1.Pod{}, "Unknown"},
+		{"terminating", &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}, "Terminating"},
+		{"crash loop", &corev1.Pod{
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{crashing}},
+		}, "CrashLoopBackOff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, getPodStatus(tt.pod))
+		})
+	}
+}
+
+func TestGetDonutStatus(t *testing.T) {
+	pods := []*corev1.Pod{
+		{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		{Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	}
+	require.Equal(t, map[string]string{
+		"Running":          "2",
+		"Pending":          "1",
+		"Failed":           "0",
+		"Succeeded":        "0",
+		"Terminating":      "0",
+		"CrashLoopBackOff": "0",
+		"Unknown":          "0",
+	}, getDonutStatus(pods))
+}
@@ -0,0 +1,69 @@
+package appserver
+
+import (
+	"net/http"
+	"strings"
+
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/appserver/topology"
+)
+
+// resourceFields are the parts of the resources that a client gets.
+type resourceFields struct {
+	metadata bool
+	status   bool
+	spec     bool
+	summary  bool
+}
+
+// defaultResourceFields leave out the spec, which is the largest part of most
+// resources and seldom shown.
+var defaultResourceFields = resourceFields{metadata: true, status: true, summary: true}
+
+// Gets the resource fields of the request from its fields parameter, which may
+// be repeated or list the fields separated by commas, e.g.
+// fields=metadata,spec. The default fields are used if it is not given.
+func fieldsParam(r *http.Request) (resourceFields, error) {
+	if err := r.ParseForm(); err != nil {
+		return resourceFields{}, errs.Wrap(err, "invalid parameters")
+	}
+	values := r.Form["fields"]
+	if len(values) == 0 {
+		return defaultResourceFields, nil
+	}
+	var fields resourceFields
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			switch name {
+			case "metadata":
+				fields.metadata = true
+			case "status":
+				fields.status = true
+			case "spec":
+				fields.spec = true
+			case "summary":
+				fields.summary = true
+			default:
+				return resourceFields{}, errs.Errorf("unknown field %q", name)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// Drops the parts of the resource that are not wanted.
+func (f resourceFields) project(r topology.Resource) topology.Resource {
+	if !f.metadata {
+		r.Metadata = nil
+	}
+	if !f.status {
+		r.Status = nil
+	}
+	if !f.spec {
+		r.Spec = nil
+	}
+	if !f.summary {
+		r.Summary = nil
+	}
+	return r
+}
@@ -0,0 +1,86 @@
+package appserver
+
+import (
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Gets the summary of an object of the kind: the replicas it asks for, the
+// images and ports of its containers or its service, and the hosts of its
+// route or ingress. Objects without any of these have no summary.
+func getResourceSummary(kind kubeclient.Kind, obj runtime.Object) *topology.ResourceSummary {
+	var summary topology.ResourceSummary
+	if kind.PodTemplate != nil {
+		if template := kind.PodTemplate(obj); template != nil {
+			addContainers(&summary, template.Spec.Containers)
+		}
+	}
+
+	switch o := obj.(type) {
+	case *deploymentconfigv1.DeploymentConfig:
+		replicas := o.Spec.Replicas
+		summary.Replicas = &replicas
+	case *appsv1.Deployment:
+		summary.Replicas = copyReplicas(o.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		summary.Replicas = copyReplicas(o.Spec.Replicas)
+	case *appsv1.ReplicaSet:
+		summary.Replicas = copyReplicas(o.Spec.Replicas)
+	case *corev1.ReplicationController:
+		summary.Replicas = copyReplicas(o.Spec.Replicas)
+	case *corev1.Pod:
+		addContainers(&summary, o.Spec.Containers)
+	case *corev1.Service:
+		for _, port := range o.Spec.Ports {
+			summary.Ports = append(summary.Ports, port.Port)
+		}
+	case *routev1.Route:
+		if o.Spec.Host != "" {
+			summary.Hosts = []string{o.Spec.Host}
+		}
+	case *extensionsv1beta1.Ingress:
+		for _, rule := range o.Spec.Rules {
+			if rule.Host != "" {
+				summary.Hosts = append(summary.Hosts, rule.Host)
+			}
+		}
+		summary.Hosts = dedupeStrings(summary.Hosts)
+	case *unstructured.Unstructured:
+		if replicas, ok, err := unstructured.NestedInt64(o.Object, "spec", "replicas"); err == nil && ok {
+			r := int32(replicas)
+			summary.Replicas = &r
+		}
+	}
+
+	if summary.Replicas == nil && len(summary.Images) == 0 && len(summary.Ports) == 0 && len(summary.Hosts) == 0 {
+		return nil
+	}
+	return &summary
+}
+
+func addContainers(summary *topology.ResourceSummary, containers []corev1.Container) {
+	for _, container := range containers {
+		summary.Images = append(summary.Images, container.Image)
+		for _, port := range container.Ports {
+			summary.Ports = append(summary.Ports, port.ContainerPort)
+		}
+	}
+	summary.Images = dedupeStrings(summary.Images)
+}
+
+// Copies the replicas so that the summary does not refer into the object.
+func copyReplicas(replicas *int32) *int32 {
+	if replicas == nil {
+		return nil
+	}
+	r := *replicas
+	return &r
+}
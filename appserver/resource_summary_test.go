@@ -0,0 +1,67 @@
+package appserver
+
+import (
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetResourceSummary(t *testing.T) {
+	replicas := int32(2)
+	tests := map[string]struct {
+		obj      runtime.Object
+		expected *topology.ResourceSummary
+	}{
+		"deployment config": {
+			obj: &deploymentconfigv1.DeploymentConfig{
+				Spec: deploymentconfigv1.DeploymentConfigSpec{
+					Replicas: 2,
+					Template: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{
+							{Image: "nodejs:10", Ports: []corev1.ContainerPort{{ContainerPort: 8080}}},
+							{Image: "nodejs:10"},
+						}},
+					},
+				},
+			},
+			expected: &topology.ResourceSummary{Replicas: &replicas, Images: []string{"nodejs:10"}, Ports: []int32{8080}},
+		},
+		"service": {
+			obj: &corev1.Service{
+				Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}, {Port: 443}}},
+			},
+			expected: &topology.ResourceSummary{Ports: []int32{80, 443}},
+		},
+		"route": {
+			obj:      &routev1.Route{Spec: routev1.RouteSpec{Host: "nodejs.example.com"}},
+			expected: &topology.ResourceSummary{Hosts: []string{"nodejs.example.com"}},
+		},
+		"custom resource": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "etcd.database.coreos.com/v1beta2",
+				"kind":       "EtcdCluster",
+				"spec":       map[string]interface{}{"size": int64(3), "replicas": int64(2)},
+			}},
+			expected: &topology.ResourceSummary{Replicas: &replicas},
+		},
+		"nothing to summarize": {
+			obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nodejs-1-abcde"}},
+		},
+	}
+	kinds := kubeclient.NewDefaultKindRegistry()
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			kind, _ := kinds.KindOf(tt.obj)
+			require.Equal(t, tt.expected, getResourceSummary(kind, tt.obj))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package appserver
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// replicaSetRevisionAnnotation is the annotation of a replica set that
+	// holds its revision within its deployment.
+	replicaSetRevisionAnnotation = "deployment.kubernetes.io/revision"
+	// deploymentConfigVersionAnnotation is the annotation of a replication
+	// controller that holds its version within its deployment config.
+	deploymentConfigVersionAnnotation = "openshift.io/deployment-config.latest-version"
+)
+
+// Gets the revision of a replica set or replication controller within the
+// workload that rolled it out, or 0 if the object has none.
+func getRevision(obj runtime.Object) int64 {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return 0
+	}
+	for _, annotation := range []string{replicaSetRevisionAnnotation, deploymentConfigVersionAnnotation} {
+		if value, ok := o.GetAnnotations()[annotation]; ok {
+			revision, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0
+			}
+			return revision
+		}
+	}
+	return 0
+}
+
+// Sorts the resources by kind, the revisions of a kind from the oldest to the
+// latest and resources of the same revision by namespace and name.
+func sortResources(resources []topology.Resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Revision != b.Revision {
+			return a.Revision < b.Revision
+		}
+		return a.Key() < b.Key()
+	})
+}
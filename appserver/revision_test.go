@@ -0,0 +1,45 @@
+package appserver
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetRevision(t *testing.T) {
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{replicaSetRevisionAnnotation: "3"},
+	}}
+	require.Equal(t, int64(3), getRevision(rs))
+
+	rc := &corev1.ReplicationController{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{deploymentConfigVersionAnnotation: "12"},
+	}}
+	require.Equal(t, int64(12), getRevision(rc))
+
+	invalid := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{replicaSetRevisionAnnotation: "latest"},
+	}}
+	require.Equal(t, int64(0), getRevision(invalid))
+	require.Equal(t, int64(0), getRevision(&corev1.Service{}))
+}
+
+func TestSortResources(t *testing.T) {
+	resources := []topology.Resource{
+		{Kind: "ReplicaSet", Name: "nodejs-b", Revision: 10},
+		{Kind: "Pod", Name: "nodejs-b-2"},
+		{Kind: "ReplicaSet", Name: "nodejs-a", Revision: 2},
+		{Kind: "Pod", Name: "nodejs-b-1"},
+	}
+	sortResources(resources)
+	var keys []string
+	for _, r := range resources {
+		keys = append(keys, r.Key())
+	}
+	require.Equal(t, []string{"Pod/nodejs-b-1", "Pod/nodejs-b-2", "ReplicaSet/nodejs-a", "ReplicaSet/nodejs-b"}, keys)
+}
@@ -0,0 +1,145 @@
+package appserver
+
+import (
+	"sort"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// deploymentPhaseAnnotation is the annotation of a replication controller
+	// that holds the phase of the rollout of its deployment config.
+	deploymentPhaseAnnotation = "openshift.io/deployment.phase"
+	// progressDeadlineExceeded is the reason of the progressing condition of
+	// a deployment whose rollout got stuck.
+	progressDeadlineExceeded = "ProgressDeadlineExceeded"
+)
+
+// A replica set or replication controller of a workload with its revision.
+type revision struct {
+	obj      runtime.Object
+	revision int64
+}
+
+// Gets the status of the rollout of a deployment or deployment config from
+// its cached replica sets or replication controllers and their pods. The
+// revisions with the highest numbers are the current and the previous one.
+// Other nodes and workloads that never rolled out have no rollout status.
+func getRolloutStatus(c *kubeclient.Cache, node runtime.Object) *topology.RolloutStatus {
+	var phase string
+	switch n := node.(type) {
+	case *appsv1.Deployment:
+		phase = getDeploymentPhase(n)
+	case *deploymentconfigv1.DeploymentConfig:
+		if n.Spec.Paused {
+			phase = topology.RolloutPaused
+		}
+	default:
+		return nil
+	}
+	o, err := meta.Accessor(node)
+	if err != nil {
+		return nil
+	}
+
+	var revisions []revision
+	for _, obj := range c.ByIndex(kubeclient.OwnerIndex, string(o.GetUID())) {
+		switch obj.(type) {
+		case *appsv1.ReplicaSet, *corev1.ReplicationController:
+			revisions = append(revisions, revision{obj: obj, revision: getRevision(obj)})
+		}
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].revision > revisions[j].revision
+	})
+
+	status := &topology.RolloutStatus{
+		Phase:   phase,
+		Current: getRevisionStatus(c, revisions[0]),
+	}
+	if len(revisions) > 1 {
+		status.Previous = getRevisionStatus(c, revisions[1])
+	}
+	if rc, ok := revisions[0].obj.(*corev1.ReplicationController); ok && status.Phase == "" {
+		status.Phase = getReplicationControllerPhase(rc)
+	}
+	return status
+}
+
+// Gets the phase of the rollout of a deployment the way that kubectl rollout
+// status tells it.
+func getDeploymentPhase(d *appsv1.Deployment) string {
+	if d.Spec.Paused {
+		return topology.RolloutPaused
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == progressDeadlineExceeded {
+			return topology.RolloutFailed
+		}
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas {
+		return topology.RolloutComplete
+	}
+	return topology.RolloutProgressing
+}
+
+// Gets the phase of the rollout of a deployment config from the phase of its
+// latest replication controller.
+func getReplicationControllerPhase(rc *corev1.ReplicationController) string {
+	switch deploymentconfigv1.DeploymentStatus(rc.Annotations[deploymentPhaseAnnotation]) {
+	case deploymentconfigv1.DeploymentStatusComplete:
+		return topology.RolloutComplete
+	case deploymentconfigv1.DeploymentStatusFailed:
+		return topology.RolloutFailed
+	default:
+		return topology.RolloutProgressing
+	}
+}
+
+// Gets the status of the revision with the counts of the cached pods that it
+// controls.
+func getRevisionStatus(c *kubeclient.Cache, r revision) *topology.RevisionStatus {
+	o, err := meta.Accessor(r.obj)
+	if err != nil {
+		return nil
+	}
+	var replicas *int32
+	switch obj := r.obj.(type) {
+	case *appsv1.ReplicaSet:
+		replicas = obj.Spec.Replicas
+	case *corev1.ReplicationController:
+		replicas = obj.Spec.Replicas
+	}
+	var pods []*corev1.Pod
+	for _, obj := range c.ByIndex(kubeclient.OwnerIndex, string(o.GetUID())) {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	status := &topology.RevisionStatus{
+		Name:     o.GetName(),
+		Revision: r.revision,
+		Pods:     getDonutStatus(pods),
+	}
+	if replicas != nil {
+		status.Replicas = *replicas
+	}
+	return status
+}
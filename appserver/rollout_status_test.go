@@ -0,0 +1,158 @@
+package appserver
+
+import (
+	"testing"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAppServer_RolloutStatus(t *testing.T) {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 1},
+	}
+	replicaSet := func(name string, revision string, replicas int32) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "myproject",
+				UID:             types.UID(name),
+				Annotations:     map[string]string{replicaSetRevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+		}
+	}
+	pod := func(name string, owner *appsv1.ReplicaSet, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "myproject",
+				UID:             types.UID(name),
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	first := replicaSet("nodejs-1", "1", 0)
+	second := replicaSet("nodejs-2", "2", 2)
+	third := replicaSet("nodejs-3", "3", 1)
+	k := test.FakeKubeClient(deployment, first, second, third,
+		pod("nodejs-2-a", second, corev1.PodRunning),
+		pod("nodejs-2-b", second, corev1.PodRunning),
+		pod("nodejs-3-a", third, corev1.PodPending),
+	)
+
+	snapshot, err := getTopologySnapshot(kubeclient.NewDefaultKindRegistry(), k, defaultTopologyView(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Graph.Nodes, 1)
+
+	// The latest two revisions are the current and the previous one.
+	status := snapshot.Topology[topology.NodeID(snapshot.Graph.Nodes[0].ID)].Data.RolloutStatus
+	require.NotNil(t, status)
+	require.Equal(t, topology.RolloutProgressing, status.Phase)
+	require.Equal(t, "nodejs-3", status.Current.Name)
+	require.Equal(t, int64(3), status.Current.Revision)
+	require.Equal(t, int32(1), status.Current.Replicas)
+	require.Equal(t, "1", status.Current.Pods[podStatusPending])
+	require.Equal(t, "0", status.Current.Pods[podStatusRunning])
+	require.Equal(t, "nodejs-2", status.Previous.Name)
+	require.Equal(t, int64(2), status.Previous.Revision)
+	require.Equal(t, "2", status.Previous.Pods[podStatusRunning])
+}
+
+func TestAppServer_RolloutStatusDeploymentConfig(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	rc := func(version string, phase deploymentconfigv1.DeploymentStatus) *corev1.ReplicationController {
+		return &corev1.ReplicationController{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nodejs-" + version,
+				Namespace: "myproject",
+				UID:       types.UID("nodejs-" + version),
+				Annotations: map[string]string{
+					deploymentConfigVersionAnnotation: version,
+					deploymentPhaseAnnotation:         string(phase),
+				},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dc, deploymentconfigv1.GroupVersion.WithKind("DeploymentConfig"))},
+			},
+		}
+	}
+
+	for phase, expected := range map[deploymentconfigv1.DeploymentStatus]string{
+		deploymentconfigv1.DeploymentStatusRunning:  topology.RolloutProgressing,
+		deploymentconfigv1.DeploymentStatusComplete: topology.RolloutComplete,
+		deploymentconfigv1.DeploymentStatusFailed:   topology.RolloutFailed,
+	} {
+		k := test.FakeKubeClient(dc, rc("1", deploymentconfigv1.DeploymentStatusComplete), rc("2", phase))
+		c, err := kubeclient.ListCache(k, kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject")
+		require.NoError(t, err)
+		status := getRolloutStatus(c, dc)
+		require.Equal(t, expected, status.Phase, string(phase))
+		require.Equal(t, "nodejs-2", status.Current.Name)
+		require.Equal(t, "nodejs-1", status.Previous.Name)
+	}
+
+	// A paused deployment config stays paused whatever its latest revision
+	// does.
+	paused := dc.DeepCopy()
+	paused.Spec.Paused = true
+	k := test.FakeKubeClient(paused, rc("1", deploymentconfigv1.DeploymentStatusRunning))
+	c, err := kubeclient.ListCache(k, kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	status := getRolloutStatus(c, paused)
+	require.Equal(t, topology.RolloutPaused, status.Phase)
+	require.Nil(t, status.Previous)
+
+	// Without any revision there is no rollout to follow.
+	other := dc.DeepCopy()
+	other.UID = "2"
+	require.Nil(t, getRolloutStatus(c, other))
+}
+
+func TestGetDeploymentPhase(t *testing.T) {
+	replicas := int32(2)
+	complete := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 3},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 3,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  2,
+		},
+	}
+	require.Equal(t, topology.RolloutComplete, getDeploymentPhase(&complete))
+
+	outdated := complete
+	outdated.Generation = 4
+	require.Equal(t, topology.RolloutProgressing, getDeploymentPhase(&outdated))
+
+	unavailable := complete
+	unavailable.Status.AvailableReplicas = 1
+	require.Equal(t, topology.RolloutProgressing, getDeploymentPhase(&unavailable))
+
+	failed := unavailable
+	failed.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: progressDeadlineExceeded,
+	}}
+	require.Equal(t, topology.RolloutFailed, getDeploymentPhase(&failed))
+
+	paused := failed
+	paused.Spec.Paused = true
+	require.Equal(t, topology.RolloutPaused, getDeploymentPhase(&paused))
+}
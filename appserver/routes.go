@@ -16,6 +16,29 @@ func (srv *AppServer) SetupRoutes() error {
 		srv.router.HandleFunc("/topology", srv.HandleTopology()).
 			Name("topology").
 			Methods("GET")
+		srv.router.HandleFunc("/topology/snapshot", srv.HandleTopologySnapshot()).
+			Name("topology-snapshot").
+			Methods("GET")
+		// Node IDs are made of the namespace, kind and name of their object
+		// separated by slashes.
+		srv.router.HandleFunc("/topology/nodes/{id:.+}/scale", srv.HandleScaleNode()).
+			Name("topology-node-scale").
+			Methods("POST")
+		srv.router.HandleFunc("/topology/nodes/{id:.+}/restart", srv.HandleRestartNode()).
+			Name("topology-node-restart").
+			Methods("POST")
+		srv.router.HandleFunc("/topology/nodes/{id:.+}/rollback", srv.HandleRollbackNode()).
+			Name("topology-node-rollback").
+			Methods("POST")
+		srv.router.HandleFunc("/topology/edges", srv.HandleCreateEdge()).
+			Name("topology-edge-create").
+			Methods("POST")
+		srv.router.HandleFunc("/topology/edges", srv.HandleDeleteEdge()).
+			Name("topology-edge-delete").
+			Methods("DELETE")
+		srv.router.HandleFunc("/topology/applications/{name}", srv.HandleDeleteApplication()).
+			Name("topology-application-delete").
+			Methods("DELETE")
 	})
 	return err
 }
@@ -0,0 +1,163 @@
+package topology
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Message types of the topology stream.
+const (
+	// MessageTypeFull is the type of a message that carries the whole
+	// topology.
+	MessageTypeFull = "full"
+	// MessageTypeDelta is the type of a message that carries the changes
+	// since the previous message.
+	MessageTypeDelta = "delta"
+)
+
+// StreamMessage is a message of the topology stream. The first message of a
+// stream carries the full topology and every following message the delta to
+// its predecessor. Seq increases by one with every message so that a client
+// can resume a stream from the last message it has seen.
+type StreamMessage struct {
+	Type   string                 `json:"type,name=type"`
+	Stream string                 `json:"stream,name=stream"`
+	Seq    uint64                 `json:"seq,name=seq"`
+	Full   *VisualizationResponse `json:"full,omitempty"`
+	Delta  *Delta                 `json:"delta,omitempty"`
+}
+
+// Delta contains the entries that were added, updated or removed between
+// two versions of a topology. Edges are never updated, a changed edge is
+// removed and added again.
+type Delta struct {
+	Added   DeltaEntries `json:"added,name=added"`
+	Updated DeltaEntries `json:"updated,name=updated"`
+	Removed DeltaEntries `json:"removed,name=removed"`
+}
+
+// DeltaEntries are the nodes, edges, groups and node data of a delta.
+type DeltaEntries struct {
+	Nodes    []Node   `json:"nodes,omitempty"`
+	Edges    []Edge   `json:"edges,omitempty"`
+	Groups   []Group  `json:"groups,omitempty"`
+	Topology Topology `json:"topology,omitempty"`
+}
+
+// IsEmpty tells whether the delta does not contain any change.
+func (d Delta) IsEmpty() bool {
+	return d.Added.isEmpty() && d.Updated.isEmpty() && d.Removed.isEmpty()
+}
+
+func (e DeltaEntries) isEmpty() bool {
+	return len(e.Nodes) == 0 && len(e.Edges) == 0 && len(e.Groups) == 0 && len(e.Topology) == 0
+}
+
+// Diff computes the delta that turns the previous topology into the next one.
+func Diff(prev VisualizationResponse, next VisualizationResponse) Delta {
+	var d Delta
+
+	// Nodes are identified by their ID.
+	prevNodes := make(map[string]Node)
+	for _, n := range prev.Nodes {
+		prevNodes[n.ID] = n
+	}
+	nextNodes := make(map[string]Node)
+	for _, n := range next.Nodes {
+		nextNodes[n.ID] = n
+		old, ok := prevNodes[n.ID]
+		if !ok {
+			d.Added.Nodes = append(d.Added.Nodes, n)
+		} else if !reflect.DeepEqual(old, n) {
+			d.Updated.Nodes = append(d.Updated.Nodes, n)
+		}
+	}
+	for _, n := range prev.Nodes {
+		if _, ok := nextNodes[n.ID]; !ok {
+			d.Removed.Nodes = append(d.Removed.Nodes, n)
+		}
+	}
+
+	// Edges are compared by value.
+	prevEdges := make(map[Edge]bool)
+	for _, e := range prev.Edges {
+		prevEdges[e] = true
+	}
+	nextEdges := make(map[Edge]bool)
+	for _, e := range next.Edges {
+		nextEdges[e] = true
+		if !prevEdges[e] {
+			d.Added.Edges = append(d.Added.Edges, e)
+		}
+	}
+	for _, e := range prev.Edges {
+		if !nextEdges[e] {
+			d.Removed.Edges = append(d.Removed.Edges, e)
+		}
+	}
+
+	// Groups are identified by their ID and the order of their nodes does
+	// not matter.
+	prevGroups := make(map[string]Group)
+	for _, g := range prev.Groups {
+		prevGroups[g.ID] = g
+	}
+	nextGroups := make(map[string]Group)
+	for _, g := range next.Groups {
+		nextGroups[g.ID] = g
+		old, ok := prevGroups[g.ID]
+		if !ok {
+			d.Added.Groups = append(d.Added.Groups, g)
+		} else if !equalGroups(old, g) {
+			d.Updated.Groups = append(d.Updated.Groups, g)
+		}
+	}
+	for _, g := range prev.Groups {
+		if _, ok := nextGroups[g.ID]; !ok {
+			d.Removed.Groups = append(d.Removed.Groups, g)
+		}
+	}
+
+	// Node data is identified by its node ID.
+	for id, nd := range next.Topology {
+		old, ok := prev.Topology[id]
+		if !ok {
+			d.Added.Topology = addNodeData(d.Added.Topology, id, nd)
+		} else if !reflect.DeepEqual(old, nd) {
+			d.Updated.Topology = addNodeData(d.Updated.Topology, id, nd)
+		}
+	}
+	for id, nd := range prev.Topology {
+		if _, ok := next.Topology[id]; !ok {
+			d.Removed.Topology = addNodeData(d.Removed.Topology, id, nd)
+		}
+	}
+
+	return d
+}
+
+func addNodeData(t Topology, id NodeID, nd NodeData) Topology {
+	if t == nil {
+		t = make(Topology)
+	}
+	t[id] = nd
+	return t
+}
+
+func equalGroups(a Group, b Group) bool {
+	if a.ID != b.ID || a.Name != b.Name || a.ParentID != b.ParentID {
+		return false
+	}
+	return equalSets(a.Nodes, b.Nodes) && equalSets(a.Groups, b.Groups)
+}
+
+func equalSets(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,65 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	prev := VisualizationResponse{
+		Graph: Graph{
+			Nodes:  []Node{{ID: "1", Name: "nginx"}, {ID: "2", Name: "nodejs"}},
+			Edges:  []Edge{{ID: "1", Source: "1", Target: "2", Type: "connects-to"}},
+			Groups: []Group{{ID: "group:testapp", Name: "testapp", Nodes: []string{"1", "2"}}},
+		},
+		Topology: Topology{
+			"1": NodeData{ID: "1", Name: "nginx"},
+			"2": NodeData{ID: "2", Name: "nodejs"},
+		},
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		next := prev
+		next.Groups = []Group{{ID: "group:testapp", Name: "testapp", Nodes: []string{"2", "1"}}}
+		require.True(t, Diff(prev, next).IsEmpty())
+	})
+
+	t.Run("nested group", func(t *testing.T) {
+		next := prev
+		next.Groups = []Group{{ID: "group:testapp", Name: "testapp", Nodes: []string{"1", "2"}, Groups: []string{"group:testapp/frontend"}}}
+		require.Equal(t, next.Groups, Diff(prev, next).Updated.Groups)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		next := VisualizationResponse{
+			Graph: Graph{
+				Nodes:  []Node{{ID: "2", Name: "nodejs-renamed"}, {ID: "3", Name: "perl"}},
+				Edges:  []Edge{{ID: "3", Source: "3", Target: "2", Type: "connects-to"}},
+				Groups: []Group{{ID: "group:testapp", Name: "testapp", Nodes: []string{"2", "3"}}},
+			},
+			Topology: Topology{
+				"2": NodeData{ID: "2", Name: "nodejs-renamed"},
+				"3": NodeData{ID: "3", Name: "perl"},
+			},
+		}
+		d := Diff(prev, next)
+		require.False(t, d.IsEmpty())
+
+		require.Equal(t, []Node{{ID: "3", Name: "perl"}}, d.Added.Nodes)
+		require.Equal(t, []Node{{ID: "2", Name: "nodejs-renamed"}}, d.Updated.Nodes)
+		require.Equal(t, []Node{{ID: "1", Name: "nginx"}}, d.Removed.Nodes)
+
+		require.Equal(t, []Edge{{ID: "3", Source: "3", Target: "2", Type: "connects-to"}}, d.Added.Edges)
+		require.Empty(t, d.Updated.Edges)
+		require.Equal(t, []Edge{{ID: "1", Source: "1", Target: "2", Type: "connects-to"}}, d.Removed.Edges)
+
+		require.Empty(t, d.Added.Groups)
+		require.Equal(t, []Group{{ID: "group:testapp", Name: "testapp", Nodes: []string{"2", "3"}}}, d.Updated.Groups)
+		require.Empty(t, d.Removed.Groups)
+
+		require.Equal(t, Topology{"3": NodeData{ID: "3", Name: "perl"}}, d.Added.Topology)
+		require.Equal(t, Topology{"2": NodeData{ID: "2", Name: "nodejs-renamed"}}, d.Updated.Topology)
+		require.Equal(t, Topology{"1": NodeData{ID: "1", Name: "nginx"}}, d.Removed.Topology)
+	})
+}
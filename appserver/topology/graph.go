@@ -1,5 +1,10 @@
 package topology
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Graph contains the groupds, edges and nodes of the graph.
 type Graph struct {
 	Nodes  []Node  `json:"nodes,omitempty" protobuf:"bytes,1,opt,name=nodes"`
@@ -7,10 +12,11 @@ type Graph struct {
 	Groups []Group `json:"groups,name=groups"`
 }
 
-// Node is the id and name of a node.
+// Node is the id, name and namespace of a node.
 type Node struct {
-	ID   string `json:"id,name=id"`
-	Name string `json:"name,name=name"`
+	ID        string `json:"id,name=id"`
+	Name      string `json:"name,name=name"`
+	Namespace string `json:"namespace,name=namespace"`
 }
 
 // Edge is the source and target of a node.
@@ -21,25 +27,56 @@ type Edge struct {
 	Type   string `json:"type,name=type"`
 }
 
-// Group is a group of nodes.
+// Group is a group of nodes. Groups may be nested, in which case the nodes of
+// a group include the nodes of its nested groups.
 type Group struct {
 	ID    string   `json:"id,name=id"`
 	Name  string   `json:"name,name=name"`
 	Nodes []string `json:"nodes,name=nodes"`
+	// ParentID is the ID of the group that the group is nested in.
+	ParentID string `json:"parentId,omitempty"`
+	// Groups are the IDs of the groups nested in the group.
+	Groups []string `json:"groups,omitempty"`
 }
 
-// Resource of a node.
+// Resource of a node. Its metadata, status and spec are the JSON of the
+// respective parts of the object.
 type Resource struct {
-	Metadata string `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
-	Status   string `json:"status,omitempty" protobuf:"bytes,2,opt,name=spec"`
-	Name     string `json:"name,name=name"`
-	Kind     string `json:"kind,name=kind"`
-	Spec     string `json:"spec,name=spec"`
+	Metadata json.RawMessage  `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Status   json.RawMessage  `json:"status,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	Name     string           `json:"name,name=name"`
+	Kind     string           `json:"kind,name=kind"`
+	Spec     json.RawMessage  `json:"spec,omitempty" protobuf:"bytes,3,opt,name=spec"`
+	Summary  *ResourceSummary `json:"summary,omitempty" protobuf:"bytes,4,opt,name=summary"`
+	// Namespace is the namespace of the object, empty for cluster scoped
+	// objects.
+	Namespace string `json:"namespace,omitempty"`
+	// Revision is the revision of a replica set or replication controller
+	// within the workload that rolled it out.
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// Key identifies the object of the resource among the resources of a node.
+func (r Resource) Key() string {
+	return NodeKey(r.Namespace, r.Kind, r.Name)
+}
+
+// ResourceSummary is what a resource is mostly looked at for.
+type ResourceSummary struct {
+	// Replicas is the desired number of pods.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Images are the images of the containers of the pods.
+	Images []string `json:"images,omitempty"`
+	// Ports are the ports of a service or of the containers of the pods.
+	Ports []int32 `json:"ports,omitempty"`
+	// Hosts are the hosts of a route or an ingress.
+	Hosts []string `json:"hosts,omitempty"`
 }
 
 // NodeData is the node data.
 type NodeData struct {
 	Name      string     `json:"name,name=name"`
+	Namespace string     `json:"namespace,name=namespace"`
 	Type      string     `json:"type,name=type"`
 	ID        string     `json:"id,name=id"`
 	Resources []Resource `json:"resource,omitempty" protobuf:"bytes,1,opt,name=resource"`
@@ -52,9 +89,51 @@ type NodeID string
 // Data value
 type Data struct {
 	URL          string            `json:"url,name=url"`
+	URLs         []string          `json:"urls,omitempty" protobuf:"bytes,1,opt,name=urls"`
 	EditURL      string            `json:"editUrl,name=editUrl"`
 	BuilderImage string            `json:"builderImage,name=builderImage"`
 	DonutStatus  map[string]string `json:"donutStatus,name=donutStatus"`
+	Build        *BuildStatus      `json:"build,omitempty" protobuf:"bytes,2,opt,name=build"`
+	// RolloutStatus is the progress of the latest rollout of a deployment or
+	// deployment config.
+	RolloutStatus *RolloutStatus `json:"rolloutStatus,omitempty" protobuf:"bytes,3,opt,name=rolloutStatus"`
+}
+
+// Phases of a rollout.
+const (
+	RolloutProgressing = "progressing"
+	RolloutComplete    = "complete"
+	RolloutFailed      = "failed"
+	RolloutPaused      = "paused"
+)
+
+// RolloutStatus is the progress of the rollout of a workload from its previous
+// to its current revision.
+type RolloutStatus struct {
+	Phase    string          `json:"phase,name=phase"`
+	Current  *RevisionStatus `json:"current,omitempty" protobuf:"bytes,1,opt,name=current"`
+	Previous *RevisionStatus `json:"previous,omitempty" protobuf:"bytes,2,opt,name=previous"`
+}
+
+// RevisionStatus is a revision of a workload, i.e. one of its replica sets or
+// replication controllers, and its pods.
+type RevisionStatus struct {
+	Name     string `json:"name,name=name"`
+	Revision int64  `json:"revision,name=revision"`
+	Replicas int32  `json:"replicas,name=replicas"`
+	// Pods counts the pods of the revision per status like the donut status
+	// of the node.
+	Pods map[string]string `json:"pods,name=pods"`
+}
+
+// BuildStatus is the status of the latest build of a node.
+type BuildStatus struct {
+	Name           string     `json:"name,name=name"`
+	BuildConfig    string     `json:"buildConfig,name=buildConfig"`
+	Number         int        `json:"number,name=number"`
+	Phase          string     `json:"phase,name=phase"`
+	StartTime      *time.Time `json:"startTime,omitempty" protobuf:"bytes,3,opt,name=startTime"`
+	CompletionTime *time.Time `json:"completionTime,omitempty" protobuf:"bytes,4,opt,name=completionTime"`
 }
 
 // Topology value.
@@ -0,0 +1,59 @@
+package topology
+
+import (
+	"strings"
+
+	errs "github.com/pkg/errors"
+)
+
+// The IDs of nodes, edges and groups only depend on the objects they are made
+// of, so that they stay the same when a client reconnects or the objects
+// change.
+
+// NodeKey returns the ID of the node made of the object of the kind with the
+// name in the namespace, e.g. "myproject/Deployment/nodejs". Parts that are
+// empty are left out, e.g. the namespace of cluster scoped objects.
+func NodeKey(namespace string, kind string, name string) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{namespace, kind, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// ParseNodeKey splits the key of a node into the namespace, kind and name of
+// its object. The namespace is empty for keys without one.
+func ParseNodeKey(key string) (namespace string, kind string, name string, err error) {
+	parts := strings.Split(key, "/")
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", errs.Errorf("invalid node key %q", key)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return "", parts[0], parts[1], nil
+	case 3:
+		return parts[0], parts[1], parts[2], nil
+	default:
+		return "", "", "", errs.Errorf("invalid node key %q, expected namespace/kind/name", key)
+	}
+}
+
+// EdgeID returns the ID of the edge of the type from the source to the target
+// node, e.g. "myproject/Deployment/nodejs->myproject/Deployment/mongodb:connects-to".
+func EdgeID(source string, target string, edgeType string) string {
+	return source + "->" + target + ":" + edgeType
+}
+
+// GroupID returns the ID of the group with the name that is nested in the
+// group with the parent ID, e.g. "group:testapp/frontend". Groups that are
+// not nested have no parent ID.
+func GroupID(parentID string, name string) string {
+	if parentID == "" {
+		return "group:" + name
+	}
+	return parentID + "/" + name
+}
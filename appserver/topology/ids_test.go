@@ -0,0 +1,20 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNodeKey(t *testing.T) {
+	for _, parts := range [][]string{{"myproject", "Deployment", "nodejs"}, {"", "Node", "worker"}} {
+		namespace, kind, name, err := ParseNodeKey(NodeKey(parts[0], parts[1], parts[2]))
+		require.NoError(t, err)
+		require.Equal(t, parts, []string{namespace, kind, name})
+	}
+
+	for _, key := range []string{"", "nodejs", "myproject//nodejs", "a/b/c/d", "myproject/Deployment/"} {
+		_, _, _, err := ParseNodeKey(key)
+		require.Error(t, err, key)
+	}
+}
@@ -0,0 +1,131 @@
+package topology
+
+import "sync"
+
+// NodeMeta describes the object that a node of the topology is made of.
+type NodeMeta struct {
+	ID          string
+	Name        string
+	Namespace   string
+	Type        string
+	Kind        string
+	Value       interface{}
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Key returns the key of the node in a store, which is also its ID.
+func (m NodeMeta) Key() string {
+	return NodeKey(m.Namespace, m.Kind, m.Name)
+}
+
+// StoreEntry is a node of the store together with the data shown for it.
+type StoreEntry struct {
+	Meta NodeMeta
+	Data NodeData
+	// Services are the names of the services that select the pods of the
+	// node.
+	Services []string
+	// ServiceReferences are the names of the services that the containers of
+	// the node refer to in their environment.
+	ServiceReferences []string
+	// References are the keys of the shared objects, such as services, routes
+	// and build configs, that the data of the node was resolved from. Objects
+	// that were looked up but not found are included.
+	References map[string]bool
+}
+
+// Snapshot is a copy of the entries of a store keyed by node key. It can be
+// read without any locking while the store keeps changing.
+type Snapshot map[string]StoreEntry
+
+// Store keeps the nodes of a topology and their data. It is safe for
+// concurrent use.
+type Store struct {
+	mutex   sync.RWMutex
+	entries map[string]StoreEntry
+}
+
+// NewStore creates an empty store.
+func NewStore() *Store {
+	return &Store{entries: make(map[string]StoreEntry)}
+}
+
+// Add adds the entry to the store, replacing the entry of the node with the
+// same key.
+func (s *Store) Add(entry StoreEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[entry.Meta.Key()] = entry
+}
+
+// Update applies the update function to the entry of the node with the key
+// while holding the lock of the store. The function gets an empty entry if the
+// node does not exist yet and the result is stored either way.
+func (s *Store) Update(key string, update func(entry *StoreEntry)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry := s.entries[key]
+	update(&entry)
+	s.entries[key] = entry
+}
+
+// Delete removes the entry of the node with the key.
+func (s *Store) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, key)
+}
+
+// Get returns the entry of the node with the key.
+func (s *Store) Get(key string) (StoreEntry, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return StoreEntry{}, false
+	}
+	return copyEntry(entry), true
+}
+
+// Keys returns the keys of the entries that match. The entries are passed to
+// the match function without being copied and must not be changed.
+func (s *Store) Keys(match func(entry StoreEntry) bool) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var keys []string
+	for key, entry := range s.entries {
+		if match(entry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Snapshot returns a copy of all entries.
+func (s *Store) Snapshot() Snapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	snapshot := make(Snapshot, len(s.entries))
+	for key, entry := range s.entries {
+		snapshot[key] = copyEntry(entry)
+	}
+	return snapshot
+}
+
+// Copies the parts of an entry that an update may change in place. Labels,
+// annotations, services, references and the object itself are only ever
+// replaced as a whole.
+func copyEntry(entry StoreEntry) StoreEntry {
+	if entry.Data.Resources != nil {
+		entry.Data.Resources = append([]Resource(nil), entry.Data.Resources...)
+	}
+	if entry.Data.Data.DonutStatus != nil {
+		donutStatus := make(map[string]string, len(entry.Data.Data.DonutStatus))
+		for k, v := range entry.Data.Data.DonutStatus {
+			donutStatus[k] = v
+		}
+		entry.Data.Data.DonutStatus = donutStatus
+	}
+	return entry
+}
@@ -0,0 +1,80 @@
+package topology
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	s.Add(StoreEntry{Meta: NodeMeta{ID: "1", Name: "nodejs"}})
+
+	entry, ok := s.Get("nodejs")
+	require.True(t, ok)
+	require.Equal(t, "1", entry.Meta.ID)
+
+	s.Update("nodejs", func(entry *StoreEntry) {
+		entry.Data.Resources = append(entry.Data.Resources, Resource{Kind: "Service"})
+	})
+	s.Update("perl", func(entry *StoreEntry) {
+		entry.Meta = NodeMeta{ID: "2", Name: "perl"}
+	})
+	snapshot := s.Snapshot()
+	require.Len(t, snapshot, 2)
+	require.Len(t, snapshot["nodejs"].Data.Resources, 1)
+	require.Equal(t, "2", snapshot["perl"].Meta.ID)
+	require.Equal(t, []string{"perl"}, s.Keys(func(entry StoreEntry) bool { return entry.Meta.ID == "2" }))
+
+	s.Delete("nodejs")
+	_, ok = s.Get("nodejs")
+	require.False(t, ok)
+	require.Len(t, s.Snapshot(), 1)
+}
+
+func TestStore_SnapshotIsACopy(t *testing.T) {
+	s := NewStore()
+	s.Add(StoreEntry{
+		Meta: NodeMeta{ID: "1", Name: "nodejs"},
+		Data: NodeData{
+			Resources: []Resource{{Kind: "Service"}},
+			Data:      Data{DonutStatus: map[string]string{"Running": "1"}},
+		},
+	})
+	snapshot := s.Snapshot()
+
+	s.Update("nodejs", func(entry *StoreEntry) {
+		entry.Data.Resources[0].Kind = "Route"
+		entry.Data.Data.DonutStatus["Running"] = "2"
+	})
+
+	require.Equal(t, "Service", snapshot["nodejs"].Data.Resources[0].Kind)
+	require.Equal(t, "1", snapshot["nodejs"].Data.Data.DonutStatus["Running"])
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	s := NewStore()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("node-%d", i%2)
+			for j := 0; j < 100; j++ {
+				s.Update(name, func(entry *StoreEntry) {
+					entry.Meta.Name = name
+					entry.Data.Resources = append(entry.Data.Resources, Resource{Kind: "Service"})
+				})
+				for _, entry := range s.Snapshot() {
+					_ = len(entry.Data.Resources)
+				}
+				if j%10 == 0 {
+					s.Delete(name)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,57 @@
+package appserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait is the time given to write a message to the client.
+	writeWait = 10 * time.Second
+	// pongWait is the time the client has to answer a ping before the
+	// connection is considered dead.
+	pongWait = 60 * time.Second
+	// pingPeriod is the interval of the pings sent to the client. It must be
+	// shorter than pongWait.
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize is the largest message accepted from the client, which
+	// is not expected to send anything but control frames.
+	maxMessageSize = 512
+)
+
+// Reads from the web socket until the connection fails, is closed by the
+// client or the client stops answering pings, then cancels the session.
+// Reading is required to process the control frames of the client.
+func readPump(ws *websocket.Conn, cancel context.CancelFunc) {
+	defer cancel()
+	ws.SetReadLimit(maxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := ws.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// Pings the client periodically until the context is done. The session is
+// cancelled if a ping cannot be sent.
+func pingPump(ctx context.Context, ws *websocket.Conn, cancel context.CancelFunc) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				cancel()
+				return
+			}
+		}
+	}
+}
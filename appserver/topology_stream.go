@@ -0,0 +1,150 @@
+package appserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// streamHistoryLength is the number of deltas a stream keeps so that a
+	// client can resume it.
+	streamHistoryLength = 100
+	// streamRetention is the duration for which a stream can be resumed
+	// after its client went away.
+	streamRetention = 2 * time.Minute
+)
+
+// topologyStream is the versioned sequence of topology messages sent to one
+// client. It outlives the web socket connection for a while so that the
+// client can reconnect and resume it.
+type topologyStream struct {
+	mutex sync.Mutex
+	id    string
+	owner string
+	// scope names the namespaces that the stream shows.
+	scope   string
+	seq     uint64
+	last    *topology.VisualizationResponse
+	history []topology.StreamMessage
+
+	attached   bool
+	detachedAt time.Time
+}
+
+// next returns the message that brings the client from the last sent
+// topology to the current one. It returns nil if nothing changed.
+func (s *topologyStream) next(current topology.VisualizationResponse) *topology.StreamMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var msg topology.StreamMessage
+	if s.last == nil {
+		msg = topology.StreamMessage{Type: topology.MessageTypeFull, Full: &current}
+		s.history = nil
+	} else {
+		delta := topology.Diff(*s.last, current)
+		if delta.IsEmpty() {
+			return nil
+		}
+		msg = topology.StreamMessage{Type: topology.MessageTypeDelta, Delta: &delta}
+	}
+	s.seq++
+	msg.Stream = s.id
+	msg.Seq = s.seq
+	s.last = &current
+
+	// Only deltas are kept since a full message restarts the history.
+	if msg.Type == topology.MessageTypeDelta {
+		s.history = append(s.history, msg)
+		if len(s.history) > streamHistoryLength {
+			s.history = s.history[len(s.history)-streamHistoryLength:]
+		}
+	}
+	return &msg
+}
+
+// since returns all messages after the given sequence number. It returns
+// false if the stream cannot be resumed from there.
+func (s *topologyStream) since(seq uint64) ([]topology.StreamMessage, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.last == nil || seq > s.seq {
+		return nil, false
+	}
+	first := s.seq - uint64(len(s.history)) + 1
+	if seq+1 < first {
+		return nil, false
+	}
+	msgs := make([]topology.StreamMessage, 0, s.seq-seq)
+	msgs = append(msgs, s.history[seq+1-first:]...)
+	return msgs, true
+}
+
+// reset makes the next message carry the full topology again.
+func (s *topologyStream) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.last = nil
+}
+
+// streamRegistry keeps the topology streams that are either in use or can
+// still be resumed.
+type streamRegistry struct {
+	mutex   sync.Mutex
+	streams map[string]*topologyStream
+}
+
+func newStreamRegistry() *streamRegistry {
+	return &streamRegistry{streams: make(map[string]*topologyStream)}
+}
+
+// create registers a new stream of the owner for the scope.
+func (r *streamRegistry) create(owner string, scope string) *topologyStream {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.removeExpired()
+	s := &topologyStream{
+		id:       uuid.NewV4().String(),
+		owner:    owner,
+		scope:    scope,
+		attached: true,
+	}
+	r.streams[s.id] = s
+	return s
+}
+
+// resume hands out a stream of the owner for the scope that is not in use
+// anymore.
+func (r *streamRegistry) resume(id string, owner string, scope string) (*topologyStream, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.removeExpired()
+	s, ok := r.streams[id]
+	if !ok || s.attached || s.owner != owner || s.scope != scope {
+		return nil, false
+	}
+	s.attached = true
+	return s, true
+}
+
+// detach marks the stream as no longer in use so that it can be resumed
+// until it expires.
+func (r *streamRegistry) detach(s *topologyStream) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	s.attached = false
+	s.detachedAt = time.Now()
+}
+
+func (r *streamRegistry) removeExpired() {
+	for id, s := range r.streams {
+		if !s.attached && time.Since(s.detachedAt) > streamRetention {
+			delete(r.streams, id)
+		}
+	}
+}
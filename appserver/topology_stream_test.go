@@ -0,0 +1,96 @@
+package appserver
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/app-service/appserver/topology"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleTopology(names ...string) topology.VisualizationResponse {
+	var nodes []topology.Node
+	for _, name := range names {
+		nodes = append(nodes, topology.Node{ID: name, Name: name})
+	}
+	return topology.GetSampleTopology(nodes, nil, nil, nil)
+}
+
+func TestTopologyStream_Next(t *testing.T) {
+	streams := newStreamRegistry()
+	stream := streams.create("owner", "myproject")
+
+	// The first message carries the full topology.
+	msg := stream.next(sampleTopology("nginx"))
+	require.NotNil(t, msg)
+	require.Equal(t, topology.MessageTypeFull, msg.Type)
+	require.Equal(t, stream.id, msg.Stream)
+	require.Equal(t, uint64(1), msg.Seq)
+	require.NotNil(t, msg.Full)
+
+	// Nothing is sent if nothing changed.
+	require.Nil(t, stream.next(sampleTopology("nginx")))
+
+	// Changes are sent as deltas.
+	msg = stream.next(sampleTopology("nginx", "nodejs"))
+	require.NotNil(t, msg)
+	require.Equal(t, topology.MessageTypeDelta, msg.Type)
+	require.Equal(t, uint64(2), msg.Seq)
+	require.Equal(t, []topology.Node{{ID: "nodejs", Name: "nodejs"}}, msg.Delta.Added.Nodes)
+}
+
+func TestTopologyStream_Resume(t *testing.T) {
+	streams := newStreamRegistry()
+	stream := streams.create("owner", "myproject")
+	stream.next(sampleTopology("nginx"))
+	stream.next(sampleTopology("nginx", "nodejs"))
+	stream.next(sampleTopology("nginx", "nodejs", "perl"))
+
+	t.Run("attached streams cannot be resumed", func(t *testing.T) {
+		_, ok := streams.resume(stream.id, "owner", "myproject")
+		require.False(t, ok)
+	})
+
+	streams.detach(stream)
+
+	t.Run("other namespace", func(t *testing.T) {
+		_, ok := streams.resume(stream.id, "owner", "otherproject")
+		require.False(t, ok)
+	})
+
+	t.Run("other owner", func(t *testing.T) {
+		_, ok := streams.resume(stream.id, "other", "myproject")
+		require.False(t, ok)
+	})
+
+	t.Run("unknown stream", func(t *testing.T) {
+		resumed, missed := resumeStream(streams, "owner", "myproject", "unknown", "1")
+		require.NotEqual(t, stream.id, resumed.id)
+		require.Empty(t, missed)
+	})
+
+	t.Run("missed deltas", func(t *testing.T) {
+		resumed, missed := resumeStream(streams, "owner", "myproject", stream.id, "1")
+		require.Equal(t, stream, resumed)
+		require.Len(t, missed, 2)
+		require.Equal(t, uint64(2), missed[0].Seq)
+		require.Equal(t, uint64(3), missed[1].Seq)
+		streams.detach(resumed)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		resumed, missed := resumeStream(streams, "owner", "myproject", stream.id, "3")
+		require.Equal(t, stream, resumed)
+		require.Empty(t, missed)
+		require.Nil(t, resumed.next(sampleTopology("nginx", "nodejs", "perl")))
+		streams.detach(resumed)
+	})
+
+	t.Run("sequence number out of range", func(t *testing.T) {
+		resumed, missed := resumeStream(streams, "owner", "myproject", stream.id, "42")
+		require.Equal(t, stream, resumed)
+		require.Empty(t, missed)
+		msg := resumed.next(sampleTopology("nginx", "nodejs", "perl"))
+		require.Equal(t, topology.MessageTypeFull, msg.Type)
+		require.Equal(t, uint64(4), msg.Seq)
+	})
+}
@@ -30,6 +30,8 @@ const (
 	// support it via the 'Accept-Encoding' header.
 	DefaultHTTPCompressResponses = false
 
+	varHTTPAllowedOrigins = "http.allowed_origins"
+
 	varLogLevel = "log.level"
 	// DefaultLogLevel is the default log level used in your service.
 	DefaultLogLevel = "info"
@@ -50,6 +52,107 @@ const (
 	varHTTPReadTimeout = "http.read_timeout"
 	// DefaultHTTPReadTimeout specifies the default timeout for HTTP reads
 	DefaultHTTPReadTimeout = time.Second * 15
+
+	varKubernetesAPIURL = "kubernetes.api_url"
+	// DefaultKubernetesAPIURL is the URL of the API server that the requests
+	// of the callers are sent to.
+	DefaultKubernetesAPIURL = "https://kubernetes.default.svc"
+
+	varKubernetesClientMode = "kubernetes.client_mode"
+	// DefaultKubernetesClientMode is the way the service connects to the API
+	// server by default.
+	DefaultKubernetesClientMode = KubernetesClientModeToken
+
+	varKubernetesKubeconfig = "kubernetes.kubeconfig"
+	// DefaultKubernetesKubeconfig is the path of the kubeconfig file used in
+	// the kubeconfig client mode. When empty, $KUBECONFIG and ~/.kube/config
+	// are used.
+	DefaultKubernetesKubeconfig = ""
+
+	varKubernetesContext = "kubernetes.context"
+	// DefaultKubernetesContext is the kubeconfig context used in the
+	// kubeconfig client mode. When empty, the current context is used.
+	DefaultKubernetesContext = ""
+
+	varKubernetesCAFile = "kubernetes.ca_file"
+	// DefaultKubernetesCAFile is the path of the CA bundle that signed the API
+	// server certificate. When empty, the system roots (or the CA of the
+	// in-cluster config or kubeconfig) are used.
+	DefaultKubernetesCAFile = ""
+
+	varKubernetesCertFile = "kubernetes.cert_file"
+	// DefaultKubernetesCertFile is the path of the client certificate that is
+	// presented to the API server.
+	DefaultKubernetesCertFile = ""
+
+	varKubernetesKeyFile = "kubernetes.key_file"
+	// DefaultKubernetesKeyFile is the path of the key of the client
+	// certificate.
+	DefaultKubernetesKeyFile = ""
+
+	varKubernetesInsecure = "kubernetes.insecure"
+	// DefaultKubernetesInsecure is a switch to turn off the verification of
+	// the API server certificate. Only ever turn it on for development.
+	DefaultKubernetesInsecure = false
+
+	varKubernetesAnonymousAccess = "kubernetes.anonymous_access"
+	// DefaultKubernetesAnonymousAccess is a switch to let callers without a
+	// bearer token act with the credentials of the service in the in-cluster
+	// and kubeconfig client modes. They all share one identity then, so
+	// they see what the service sees and can resume each other's streams.
+	// Only ever turn it on for development.
+	DefaultKubernetesAnonymousAccess = false
+
+	varCacheResyncPeriod = "cache.resync_period"
+	// DefaultCacheResyncPeriod is the interval in which the shared informer
+	// caches resync their objects. Zero disables resyncs.
+	DefaultCacheResyncPeriod = time.Duration(0)
+
+	varCacheSyncTimeout = "cache.sync_timeout"
+	// DefaultCacheSyncTimeout is the duration for which a new shared informer
+	// cache may take to list all resources of a namespace
+	DefaultCacheSyncTimeout = time.Second * 30
+
+	varTopologyOperatorBackedKinds = "topology.operator_backed_kinds"
+
+	varTopologyGroupLabels = "topology.group_labels"
+)
+
+// DefaultHTTPAllowedOrigins are the origins of the web pages, other than the
+// service itself, that may open a topology web socket by default. Browsers
+// send the access_token cookie along with requests from any page, so only
+// trusted origins may be added, e.g. "https://console.example.com".
+var DefaultHTTPAllowedOrigins = []string{}
+
+// DefaultTopologyOperatorBackedKinds are the custom resource kinds that are
+// shown as operator-backed nodes of the topology by default.
+var DefaultTopologyOperatorBackedKinds = []string{}
+
+// DefaultTopologyGroupLabels are the labels that group the nodes of the
+// topology by default: into applications, their components and the instances
+// of those.
+var DefaultTopologyGroupLabels = []string{
+	"app.kubernetes.io/part-of",
+	"app.kubernetes.io/component",
+	"app.kubernetes.io/instance",
+}
+
+// The ways the service can connect to the API server.
+const (
+	// KubernetesClientModeToken connects to the configured API server URL
+	// with the bearer token of the caller only. Callers without a token are
+	// rejected.
+	KubernetesClientModeToken = "token"
+	// KubernetesClientModeInCluster connects with the service account of the
+	// pod the service runs in, which fills the shared caches. Callers act
+	// with their own bearer token and are rejected without one, unless
+	// anonymous access is turned on.
+	KubernetesClientModeInCluster = "in-cluster"
+	// KubernetesClientModeKubeconfig connects with a context of a kubeconfig
+	// file, which fills the shared caches. Callers act with their own bearer
+	// token and are rejected without one, unless anonymous access is turned
+	// on.
+	KubernetesClientModeKubeconfig = "kubeconfig"
 )
 
 // Registry encapsulates the Viper configuration registry which stores the
@@ -90,9 +193,23 @@ func (c *Registry) setConfigDefaults() {
 	c.v.SetDefault(varHTTPWriteTimeout, DefaultHTTPWriteTimeout)
 	c.v.SetDefault(varHTTPReadTimeout, DefaultHTTPReadTimeout)
 	c.v.SetDefault(varHTTPIdleTimeout, DefaultHTTPIdleTimeout)
+	c.v.SetDefault(varHTTPAllowedOrigins, DefaultHTTPAllowedOrigins)
 	c.v.SetDefault(varLogLevel, DefaultLogLevel)
 	c.v.SetDefault(varLogJSON, DefaultLogJSON)
 	c.v.SetDefault(varGracefulTimeout, DefaultGracefulTimeout)
+	c.v.SetDefault(varKubernetesAPIURL, DefaultKubernetesAPIURL)
+	c.v.SetDefault(varKubernetesClientMode, DefaultKubernetesClientMode)
+	c.v.SetDefault(varKubernetesKubeconfig, DefaultKubernetesKubeconfig)
+	c.v.SetDefault(varKubernetesContext, DefaultKubernetesContext)
+	c.v.SetDefault(varKubernetesCAFile, DefaultKubernetesCAFile)
+	c.v.SetDefault(varKubernetesCertFile, DefaultKubernetesCertFile)
+	c.v.SetDefault(varKubernetesKeyFile, DefaultKubernetesKeyFile)
+	c.v.SetDefault(varKubernetesInsecure, DefaultKubernetesInsecure)
+	c.v.SetDefault(varKubernetesAnonymousAccess, DefaultKubernetesAnonymousAccess)
+	c.v.SetDefault(varCacheResyncPeriod, DefaultCacheResyncPeriod)
+	c.v.SetDefault(varCacheSyncTimeout, DefaultCacheSyncTimeout)
+	c.v.SetDefault(varTopologyOperatorBackedKinds, DefaultTopologyOperatorBackedKinds)
+	c.v.SetDefault(varTopologyGroupLabels, DefaultTopologyGroupLabels)
 }
 
 // GetHTTPAddress returns the HTTP address (as set via default, config file, or
@@ -122,6 +239,13 @@ func (c *Registry) GetHTTPIdleTimeout() time.Duration {
 	return c.v.GetDuration(varHTTPIdleTimeout)
 }
 
+// GetHTTPAllowedOrigins returns the origins of the web pages, other than the
+// service itself, that may open a topology web socket (e.g.
+// "https://console.example.com")
+func (c *Registry) GetHTTPAllowedOrigins() []string {
+	return c.v.GetStringSlice(varHTTPAllowedOrigins)
+}
+
 // GetLogLevel returns the loggging level (as set via config file or environment
 // variable)
 func (c *Registry) GetLogLevel() string {
@@ -139,3 +263,85 @@ func (c *Registry) IsLogJSON() bool {
 func (c *Registry) GetGracefulTimeout() time.Duration {
 	return c.v.GetDuration(varGracefulTimeout)
 }
+
+// GetKubernetesAPIURL returns the URL of the API server (as set via default,
+// config file, or environment variable) that the requests of the callers are
+// sent to (e.g. "https://api.example.com:6443")
+func (c *Registry) GetKubernetesAPIURL() string {
+	return c.v.GetString(varKubernetesAPIURL)
+}
+
+// GetKubernetesClientMode returns the way the service connects to the API
+// server (as set via default, config file, or environment variable), which is
+// one of "token", "in-cluster" or "kubeconfig"
+func (c *Registry) GetKubernetesClientMode() string {
+	return c.v.GetString(varKubernetesClientMode)
+}
+
+// GetKubernetesKubeconfig returns the path of the kubeconfig file used in the
+// kubeconfig client mode
+func (c *Registry) GetKubernetesKubeconfig() string {
+	return c.v.GetString(varKubernetesKubeconfig)
+}
+
+// GetKubernetesContext returns the kubeconfig context used in the kubeconfig
+// client mode
+func (c *Registry) GetKubernetesContext() string {
+	return c.v.GetString(varKubernetesContext)
+}
+
+// GetKubernetesCAFile returns the path of the CA bundle that signed the API
+// server certificate
+func (c *Registry) GetKubernetesCAFile() string {
+	return c.v.GetString(varKubernetesCAFile)
+}
+
+// GetKubernetesCertFile returns the path of the client certificate that is
+// presented to the API server
+func (c *Registry) GetKubernetesCertFile() string {
+	return c.v.GetString(varKubernetesCertFile)
+}
+
+// GetKubernetesKeyFile returns the path of the key of the client certificate
+func (c *Registry) GetKubernetesKeyFile() string {
+	return c.v.GetString(varKubernetesKeyFile)
+}
+
+// IsKubernetesInsecure returns if the verification of the API server
+// certificate is turned off (as set via config file or environment variable)
+func (c *Registry) IsKubernetesInsecure() bool {
+	return c.v.GetBool(varKubernetesInsecure)
+}
+
+// IsKubernetesAnonymousAccess returns if callers without a bearer token act
+// with the credentials of the service in the in-cluster and kubeconfig client
+// modes (as set via config file or environment variable)
+func (c *Registry) IsKubernetesAnonymousAccess() bool {
+	return c.v.GetBool(varKubernetesAnonymousAccess)
+}
+
+// GetCacheResyncPeriod returns the interval in which the shared informer
+// caches resync their objects (zero disables resyncs)
+func (c *Registry) GetCacheResyncPeriod() time.Duration {
+	return c.v.GetDuration(varCacheResyncPeriod)
+}
+
+// GetCacheSyncTimeout returns the duration for which a new shared informer
+// cache may take to list all resources of a namespace - e.g. 30s or 1m
+func (c *Registry) GetCacheSyncTimeout() time.Duration {
+	return c.v.GetDuration(varCacheSyncTimeout)
+}
+
+// GetTopologyOperatorBackedKinds returns the custom resource kinds that are
+// shown as operator-backed nodes of the topology, each given as
+// kind.version.group - e.g. EtcdCluster.v1beta2.etcd.database.coreos.com
+func (c *Registry) GetTopologyOperatorBackedKinds() []string {
+	return c.v.GetStringSlice(varTopologyOperatorBackedKinds)
+}
+
+// GetTopologyGroupLabels returns the labels that group the nodes of the
+// topology. Every label nests its groups into the groups of the label before
+// it.
+func (c *Registry) GetTopologyGroupLabels() []string {
+	return c.v.GetStringSlice(varTopologyGroupLabels)
+}
@@ -265,3 +265,389 @@ func TestGetHTTPCompressResponses(t *testing.T) {
 		assert.Equal(t, newVal, config.GetHTTPCompressResponses())
 	})
 }
+
+func TestGetHTTPAllowedOrigins(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "HTTP_ALLOWED_ORIGINS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultHTTPAllowedOrigins, config.GetHTTPAllowedOrigins())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getFileConfiguration(t, "http.allowed_origins:\n  - https://console.example.com")
+		assert.Equal(t, []string{"https://console.example.com"}, config.GetHTTPAllowedOrigins())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		os.Setenv(key, "https://console.example.com https://dev.example.com")
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []string{"https://console.example.com", "https://dev.example.com"}, config.GetHTTPAllowedOrigins())
+	})
+}
+
+func TestGetKubernetesAPIURL(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_API_URL"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesAPIURL, config.GetKubernetesAPIURL())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.api_url: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesAPIURL())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesAPIURL())
+	})
+}
+
+func TestGetKubernetesClientMode(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_CLIENT_MODE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesClientMode, config.GetKubernetesClientMode())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.client_mode: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesClientMode())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesClientMode())
+	})
+}
+
+func TestGetKubernetesKubeconfig(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_KUBECONFIG"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesKubeconfig, config.GetKubernetesKubeconfig())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.kubeconfig: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesKubeconfig())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesKubeconfig())
+	})
+}
+
+func TestGetKubernetesContext(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_CONTEXT"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesContext, config.GetKubernetesContext())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.context: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesContext())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesContext())
+	})
+}
+
+func TestGetKubernetesCAFile(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_CA_FILE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesCAFile, config.GetKubernetesCAFile())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.ca_file: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesCAFile())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesCAFile())
+	})
+}
+
+func TestGetKubernetesCertFile(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_CERT_FILE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesCertFile, config.GetKubernetesCertFile())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.cert_file: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesCertFile())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesCertFile())
+	})
+}
+
+func TestGetKubernetesKeyFile(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_KEY_FILE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesKeyFile, config.GetKubernetesKeyFile())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := uuid.NewV4().String()
+		config := getFileConfiguration(t, `kubernetes.key_file: "`+newVal+`"`)
+		assert.Equal(t, newVal, config.GetKubernetesKeyFile())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := uuid.NewV4().String()
+		os.Setenv(key, newVal)
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetKubernetesKeyFile())
+	})
+}
+
+func TestIsKubernetesInsecure(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_INSECURE"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesInsecure, config.IsKubernetesInsecure())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := !configuration.DefaultKubernetesInsecure
+		config := getFileConfiguration(t, `kubernetes.insecure: "`+strconv.FormatBool(newVal)+`"`)
+		assert.Equal(t, newVal, config.IsKubernetesInsecure())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := !configuration.DefaultKubernetesInsecure
+		os.Setenv(key, strconv.FormatBool(newVal))
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.IsKubernetesInsecure())
+	})
+}
+
+func TestIsKubernetesAnonymousAccess(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "KUBERNETES_ANONYMOUS_ACCESS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultKubernetesAnonymousAccess, config.IsKubernetesAnonymousAccess())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := !configuration.DefaultKubernetesAnonymousAccess
+		config := getFileConfiguration(t, `kubernetes.anonymous_access: "`+strconv.FormatBool(newVal)+`"`)
+		assert.Equal(t, newVal, config.IsKubernetesAnonymousAccess())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := !configuration.DefaultKubernetesAnonymousAccess
+		os.Setenv(key, strconv.FormatBool(newVal))
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.IsKubernetesAnonymousAccess())
+	})
+}
+
+func TestGetCacheResyncPeriod(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "CACHE_RESYNC_PERIOD"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultCacheResyncPeriod, config.GetCacheResyncPeriod())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := 222 * time.Second
+		config := getFileConfiguration(t, `cache.resync_period: "`+newVal.String()+`"`)
+		assert.Equal(t, newVal, config.GetCacheResyncPeriod())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := 555 * time.Second
+		os.Setenv(key, newVal.String())
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetCacheResyncPeriod())
+	})
+}
+
+func TestGetCacheSyncTimeout(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "CACHE_SYNC_TIMEOUT"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultCacheSyncTimeout, config.GetCacheSyncTimeout())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		newVal := 333 * time.Second
+		config := getFileConfiguration(t, `cache.sync_timeout: "`+newVal.String()+`"`)
+		assert.Equal(t, newVal, config.GetCacheSyncTimeout())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		newVal := 666 * time.Second
+		os.Setenv(key, newVal.String())
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, newVal, config.GetCacheSyncTimeout())
+	})
+}
+
+func TestGetTopologyOperatorBackedKinds(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "TOPOLOGY_OPERATOR_BACKED_KINDS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Empty(t, config.GetTopologyOperatorBackedKinds())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getFileConfiguration(t, "topology.operator_backed_kinds:\n  - EtcdCluster.v1beta2.etcd.database.coreos.com")
+		assert.Equal(t, []string{"EtcdCluster.v1beta2.etcd.database.coreos.com"}, config.GetTopologyOperatorBackedKinds())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		os.Setenv(key, "EtcdCluster.v1beta2.etcd.database.coreos.com Kafka.v1beta1.kafka.strimzi.io")
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []string{"EtcdCluster.v1beta2.etcd.database.coreos.com", "Kafka.v1beta1.kafka.strimzi.io"}, config.GetTopologyOperatorBackedKinds())
+	})
+}
+
+func TestGetTopologyGroupLabels(t *testing.T) {
+	key := configuration.EnvPrefix + "_" + "TOPOLOGY_GROUP_LABELS"
+	resetFunc := testutils.UnsetEnvVarAndRestore(key)
+	defer resetFunc()
+
+	t.Run("default", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, configuration.DefaultTopologyGroupLabels, config.GetTopologyGroupLabels())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetFunc := testutils.UnsetEnvVarAndRestore(key)
+		defer resetFunc()
+		config := getFileConfiguration(t, "topology.group_labels:\n  - app.kubernetes.io/part-of")
+		assert.Equal(t, []string{"app.kubernetes.io/part-of"}, config.GetTopologyGroupLabels())
+	})
+
+	t.Run("env overwrite", func(t *testing.T) {
+		os.Setenv(key, "team app.kubernetes.io/part-of")
+		config := getDefaultConfiguration(t)
+		assert.Equal(t, []string{"team", "app.kubernetes.io/part-of"}, config.GetTopologyGroupLabels())
+	})
+}
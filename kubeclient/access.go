@@ -0,0 +1,69 @@
+package kubeclient
+
+import (
+	errs "github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+// CanList checks whether the client may list and watch the objects of all
+// kinds of the registry in the namespace. Optional kinds are left out since
+// they are empty to a client that may not list them. The namespace
+// metav1.NamespaceAll checks access to the whole cluster.
+func (kc KubeClient) CanList(kinds *KindRegistry, namespace string) (bool, error) {
+	for _, k := range kinds.Kinds() {
+		if k.Optional {
+			continue
+		}
+		allowed, err := kc.canListKind(k, namespace)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// DeniedOptionalKinds returns the names of the optional kinds of the registry
+// whose objects the client may not list or watch in the namespace. Objects of
+// those kinds must not be shown to the client even if a cache that was filled
+// with other credentials holds them.
+func (kc KubeClient) DeniedOptionalKinds(kinds *KindRegistry, namespace string) ([]string, error) {
+	var denied []string
+	for _, k := range kinds.Kinds() {
+		if !k.Optional {
+			continue
+		}
+		allowed, err := kc.canListKind(k, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			denied = append(denied, k.Name)
+		}
+	}
+	return denied, nil
+}
+
+// Checks whether the client may list and watch the objects of the kind in
+// the namespace.
+func (kc KubeClient) canListKind(k Kind, namespace string) (bool, error) {
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     k.Resource.Group,
+					Resource:  k.Resource.Resource,
+				},
+			},
+		}
+		result, err := kc.CoreClient.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+		if err != nil {
+			return false, errs.Wrapf(err, "failed to review access to kind %s", k.Name)
+		}
+		if !result.Status.Allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package kubeclient_test
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestKubeClient_CanList(t *testing.T) {
+	kinds := kubeclient.NewDefaultKindRegistry()
+
+	t.Run("allowed", func(t *testing.T) {
+		allowed, err := test.FakeKubeClient().CanList(kinds, metav1.NamespaceAll)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	})
+
+	t.Run("forbidden kind", func(t *testing.T) {
+		k := test.FakeKubeClient()
+		var reviewed, groups []string
+		k.CoreClient.(*fake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+			attributes := review.Spec.ResourceAttributes
+			require.Equal(t, metav1.NamespaceAll, attributes.Namespace)
+			reviewed = append(reviewed, attributes.Resource)
+			groups = append(groups, attributes.Group)
+			review.Status.Allowed = attributes.Resource != "pods"
+			return true, review, nil
+		})
+		allowed, err := k.CanList(kinds, metav1.NamespaceAll)
+		require.NoError(t, err)
+		require.False(t, allowed)
+		require.Contains(t, reviewed, "pods")
+		// Optional kinds are never reviewed.
+		require.NotContains(t, groups, "serving.knative.dev")
+	})
+}
+
+func TestKubeClient_DeniedOptionalKinds(t *testing.T) {
+	kinds := kubeclient.NewDefaultKindRegistry()
+
+	denied, err := test.FakeKubeClient().DeniedOptionalKinds(kinds, "myproject")
+	require.NoError(t, err)
+	require.Empty(t, denied)
+
+	k := test.FakeKubeClient()
+	k.CoreClient.(*fake.Clientset).PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+		attributes := review.Spec.ResourceAttributes
+		require.Equal(t, "myproject", attributes.Namespace)
+		// Only optional kinds are reviewed.
+		require.Equal(t, "serving.knative.dev", attributes.Group)
+		review.Status.Allowed = attributes.Verb == "list"
+		return true, review, nil
+	})
+	denied, err = k.DeniedOptionalKinds(kinds, "myproject")
+	require.NoError(t, err)
+	require.NotEmpty(t, denied)
+	for _, name := range denied {
+		kind, _ := kinds.Lookup(name)
+		require.True(t, kind.Optional)
+	}
+}
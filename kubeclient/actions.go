@@ -0,0 +1,75 @@
+package kubeclient
+
+import (
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	errs "github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The actions act on objects of any kind with the dynamic client, which
+// serves the built-in and the custom kinds alike. They use the credentials of
+// the client, so the API server authorizes and audits them for its user.
+
+// GetObject gets the object of the kind with the name in the namespace.
+func (kc KubeClient) GetObject(kind Kind, namespace string, name string) (*unstructured.Unstructured, error) {
+	obj, err := kc.DynamicClient.Resource(kind.Resource).Namespace(namespace).Get(name, v1.GetOptions{})
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to get %s %s/%s", kind.Name, namespace, name)
+	}
+	return obj, nil
+}
+
+// PatchObject applies the patch of the type to the object of the kind with the
+// name in the namespace. Unlike strategic merge patches, JSON patches (RFC
+// 6902) and JSON merge patches (RFC 7386) apply to custom kinds too.
+func (kc KubeClient) PatchObject(kind Kind, namespace string, name string, pt types.PatchType, patch []byte) error {
+	_, err := kc.DynamicClient.Resource(kind.Resource).Namespace(namespace).Patch(name, pt, patch, v1.UpdateOptions{})
+	if err != nil {
+		return errs.Wrapf(err, "failed to patch %s %s/%s", kind.Name, namespace, name)
+	}
+	return nil
+}
+
+// DeleteObjects deletes the objects of the kind in the namespace that match
+// the label selector, together with the objects that they own, and returns
+// their names. Not all resources can be deleted as a collection, so the
+// objects are deleted one by one. Kinds that are not served, such as the
+// OpenShift kinds on other clusters, have no objects to delete, and neither
+// have optional kinds that the client may not list.
+func (kc KubeClient) DeleteObjects(kind Kind, namespace string, selector string) ([]string, error) {
+	client := kc.DynamicClient.Resource(kind.Resource).Namespace(namespace)
+	list, err := client.List(v1.ListOptions{LabelSelector: selector})
+	if apierrors.IsNotFound(err) || kind.Optional && isUnavailable(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to list %s objects in namespace %q", kind.Name, namespace)
+	}
+	propagation := v1.DeletePropagationBackground
+	var deleted []string
+	for _, item := range list.Items {
+		err := client.Delete(item.GetName(), &v1.DeleteOptions{PropagationPolicy: &propagation})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, errs.Wrapf(err, "failed to delete %s %s/%s", kind.Name, namespace, item.GetName())
+		}
+		deleted = append(deleted, item.GetName())
+	}
+	return deleted, nil
+}
+
+// InstantiateDeploymentConfig rolls out the latest version of the deployment
+// config with the name in the namespace, even if nothing changed, like
+// "oc rollout latest".
+func (kc KubeClient) InstantiateDeploymentConfig(namespace string, name string) error {
+	request := &deploymentconfigv1.DeploymentRequest{Name: name, Latest: true, Force: true}
+	if _, err := kc.OcAppsClient.DeploymentConfigs(namespace).Instantiate(name, request); err != nil {
+		return errs.Wrapf(err, "failed to instantiate DeploymentConfig %s/%s", namespace, name)
+	}
+	return nil
+}
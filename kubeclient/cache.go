@@ -0,0 +1,456 @@
+package kubeclient
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errs "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// AppNameIndex is the name of the cache index over the namespace and the
+// app.kubernetes.io/name label of all cached objects. Its values are made with
+// AppNameKey.
+const AppNameIndex = "app-name"
+
+// OwnerIndex is the name of the cache index over the UID of the controller
+// owner of all cached objects.
+const OwnerIndex = "owner"
+
+// NamespaceIndex is the name of the cache index over the namespace of all
+// cached objects.
+const NamespaceIndex = cache.NamespaceIndex
+
+// cacheQueueLength is the number of change notifications that are buffered
+// for every subscriber of a cache. A subscriber that falls further behind is
+// dropped so that it cannot hold up the others.
+const cacheQueueLength = 1000
+
+// CacheRegistry hands out shared informer caches keyed by namespaces, filter
+// and client identity. All callers that ask for the same namespaces with the
+// same client share one set of API server watches. Callers that fill their
+// caches with the client of the service share them with everybody, so they
+// must make sure that the objects may be shown to whoever they hand them to.
+type CacheRegistry struct {
+	mutex        sync.Mutex
+	caches       map[string]*Cache
+	kinds        *KindRegistry
+	resyncPeriod time.Duration
+	syncTimeout  time.Duration
+}
+
+// Cache holds the informers for all kinds of one or more namespaces and
+// notifies its subscribers about every change.
+type Cache struct {
+	key        string
+	namespaces []string
+	filter     Filter
+	kinds      *KindRegistry
+	informers  []cache.SharedIndexInformer
+	indexers   []cache.Indexer
+	byKind     map[string][]cache.Indexer
+	stop       chan struct{}
+	refs       int
+
+	// The informers may still deliver notifications while the cache is being
+	// stopped, so they must not reach the subscribers after they were closed.
+	// Caches that never change have no subscribers.
+	notifyMutex sync.Mutex
+	subscribers map[*cacheWatch]bool
+	stopped     bool
+
+	syncOnce sync.Once
+	syncErr  error
+}
+
+// NewCacheRegistry creates a registry whose caches hold the given kinds. Their
+// informers resync every resyncPeriod (zero disables resyncs) and the
+// registry gives up waiting for the initial listing of a cache after
+// syncTimeout.
+func NewCacheRegistry(kinds *KindRegistry, resyncPeriod time.Duration, syncTimeout time.Duration) *CacheRegistry {
+	return &CacheRegistry{
+		caches:       make(map[string]*Cache),
+		kinds:        kinds,
+		resyncPeriod: resyncPeriod,
+		syncTimeout:  syncTimeout,
+	}
+}
+
+// Acquire returns the cache for the filtered objects of the namespaces and the
+// identity of the client, starting its informers with the given client if
+// nobody else uses it yet. The namespace metav1.NamespaceAll caches the whole
+// cluster. It blocks until the cache has been filled. Every successful call
+// must be paired with a call to Release.
+func (r *CacheRegistry) Acquire(kc *KubeClient, filter Filter, namespaces ...string) (*Cache, error) {
+	namespaces = uniqueNamespaces(namespaces)
+	key := kc.Identity() + "/" + scopeOf(namespaces, filter)
+	r.mutex.Lock()
+	c, ok := r.caches[key]
+	if !ok {
+		c = newCache(kc, r.kinds, filter, namespaces, r.resyncPeriod)
+		c.key = key
+		r.caches[key] = c
+	}
+	c.refs++
+	r.mutex.Unlock()
+
+	if err := c.waitForSync(r.syncTimeout); err != nil {
+		r.Release(c)
+		return nil, err
+	}
+	return c, nil
+}
+
+// Release gives up a reference to the cache. The informers of the cache are
+// stopped once the last reference is gone.
+func (r *CacheRegistry) Release(c *Cache) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c.refs--
+	if c.refs > 0 {
+		return
+	}
+	delete(r.caches, c.key)
+	close(c.stop)
+	c.notifyMutex.Lock()
+	defer c.notifyMutex.Unlock()
+	c.stopped = true
+	for w := range c.subscribers {
+		c.unsubscribe(w)
+	}
+}
+
+func newCache(kc *KubeClient, kinds *KindRegistry, filter Filter, namespaces []string, resyncPeriod time.Duration) *Cache {
+	c := &Cache{
+		namespaces:  namespaces,
+		filter:      filter,
+		kinds:       kinds,
+		byKind:      make(map[string][]cache.Indexer),
+		stop:        make(chan struct{}),
+		subscribers: make(map[*cacheWatch]bool),
+	}
+	for _, namespace := range namespaces {
+		for _, k := range filter.kindsOf(kinds) {
+			c.addInformer(k, resyncPeriod, filter.listWatch(k, k.ListWatch(kc, namespace)))
+		}
+	}
+	for _, informer := range c.informers {
+		go informer.Run(c.stop)
+	}
+	return c
+}
+
+// Create an informer that forwards all changes to the subscribers of the
+// cache.
+func (c *Cache) addInformer(k Kind, resyncPeriod time.Duration, lw cache.ListerWatcher) {
+	informer := cache.NewSharedIndexInformer(
+		lw,
+		k.Object,
+		resyncPeriod,
+		cacheIndexers(),
+	)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.notify(watch.Added, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.notify(watch.Modified, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.notify(watch.Deleted, obj)
+		},
+	})
+	c.informers = append(c.informers, informer)
+	c.addIndexer(k, informer.GetIndexer())
+}
+
+func (c *Cache) addIndexer(k Kind, indexer cache.Indexer) {
+	c.indexers = append(c.indexers, indexer)
+	c.byKind[k.Name] = append(c.byKind[k.Name], indexer)
+}
+
+// ListCache lists the filtered objects of the namespaces once and returns them
+// as a cache that never changes. It is not shared and needs no release.
+func ListCache(kc *KubeClient, kinds *KindRegistry, filter Filter, namespaces ...string) (*Cache, error) {
+	namespaces = uniqueNamespaces(namespaces)
+	c := &Cache{
+		namespaces: namespaces,
+		filter:     filter,
+		kinds:      kinds,
+		byKind:     make(map[string][]cache.Indexer),
+	}
+	for _, namespace := range namespaces {
+		if err := c.listNamespace(kc, namespace); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Cache) listNamespace(kc *KubeClient, namespace string) error {
+	for _, k := range c.filter.kindsOf(c.kinds) {
+		list, err := c.filter.listWatch(k, k.ListWatch(kc, namespace)).List(v1.ListOptions{})
+		if err != nil {
+			return errs.Wrapf(err, "failed to list objects of kind %s in namespace %q", k.Name, namespace)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return errs.Wrapf(err, "failed to extract objects of kind %s", k.Name)
+		}
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cacheIndexers())
+		for _, item := range items {
+			if err := indexer.Add(item); err != nil {
+				return errs.Wrapf(err, "failed to cache an object of kind %s", k.Name)
+			}
+		}
+		c.addIndexer(k, indexer)
+	}
+	return nil
+}
+
+func scopeOf(namespaces []string, filter Filter) string {
+	return strings.Join(namespaces, ",") + "?" + filter.String()
+}
+
+// Sorts the namespaces and drops duplicates. Any other namespace is covered
+// by metav1.NamespaceAll, which is kept on its own.
+func uniqueNamespaces(namespaces []string) []string {
+	unique := make([]string, 0, len(namespaces))
+	seen := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		if namespace == v1.NamespaceAll {
+			return []string{v1.NamespaceAll}
+		}
+		if !seen[namespace] {
+			seen[namespace] = true
+			unique = append(unique, namespace)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// Sends the change to every subscriber without waiting for any of them. A
+// subscriber whose buffer is full has missed the change, so its watch ends
+// and it has to start over from the current state of the cache.
+func (c *Cache) notify(eventType watch.EventType, obj interface{}) {
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+	c.notifyMutex.Lock()
+	defer c.notifyMutex.Unlock()
+	if c.stopped {
+		return
+	}
+	event := watch.Event{Type: eventType, Object: o}
+	for w := range c.subscribers {
+		select {
+		case w.result <- event:
+		default:
+			c.unsubscribe(w)
+		}
+	}
+}
+
+// Ends the watch of the subscriber. The caller holds the notify mutex.
+func (c *Cache) unsubscribe(w *cacheWatch) {
+	if c.subscribers[w] {
+		delete(c.subscribers, w)
+		close(w.result)
+	}
+}
+
+func (c *Cache) waitForSync(timeout time.Duration) error {
+	c.syncOnce.Do(func() {
+		synced := make([]cache.InformerSynced, 0, len(c.informers))
+		for _, informer := range c.informers {
+			synced = append(synced, informer.HasSynced)
+		}
+		stop := make(chan struct{})
+		timer := time.AfterFunc(timeout, func() { close(stop) })
+		defer timer.Stop()
+		if !cache.WaitForCacheSync(stop, synced...) {
+			c.syncErr = errs.Errorf("timed out waiting for the cache of namespaces %q to sync", c.namespaces)
+		}
+	})
+	return c.syncErr
+}
+
+// Namespaces returns the sorted namespaces that are cached. A cache of the
+// whole cluster has the single namespace metav1.NamespaceAll.
+func (c *Cache) Namespaces() []string {
+	return c.namespaces
+}
+
+// Scope describes the namespaces and the filter of the cache. Caches of the
+// same scope hold the same objects if their clients have the same access.
+func (c *Cache) Scope() string {
+	return scopeOf(c.namespaces, c.filter)
+}
+
+// Kinds returns the registry of the kinds that are cached.
+func (c *Cache) Kinds() *KindRegistry {
+	return c.kinds
+}
+
+// Selects tells whether the filter of the cache selects the object, i.e.
+// whether an object of a node kind may be a node of the topology.
+func (c *Cache) Selects(obj runtime.Object) bool {
+	k, ok := c.kinds.KindOf(obj)
+	return ok && c.filter.Selects(k, obj)
+}
+
+// List returns all cached objects.
+func (c *Cache) List() []runtime.Object {
+	var objects []runtime.Object
+	for _, indexer := range c.indexers {
+		for _, obj := range indexer.List() {
+			if o, ok := obj.(runtime.Object); ok {
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects
+}
+
+// ListKind returns all cached objects of the kind.
+func (c *Cache) ListKind(kind string) []runtime.Object {
+	var objects []runtime.Object
+	for _, indexer := range c.byKind[kind] {
+		for _, obj := range indexer.List() {
+			if o, ok := obj.(runtime.Object); ok {
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects
+}
+
+// ListKindInNamespace returns the cached objects of the kind in the
+// namespace.
+func (c *Cache) ListKindInNamespace(kind string, namespace string) []runtime.Object {
+	var objects []runtime.Object
+	for _, indexer := range c.byKind[kind] {
+		items, err := indexer.ByIndex(NamespaceIndex, namespace)
+		if err != nil {
+			continue
+		}
+		for _, obj := range items {
+			if o, ok := obj.(runtime.Object); ok {
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects
+}
+
+// ByIndex returns all cached objects whose index value matches.
+func (c *Cache) ByIndex(indexName string, value string) []runtime.Object {
+	var objects []runtime.Object
+	for _, indexer := range c.indexers {
+		items, err := indexer.ByIndex(indexName, value)
+		if err != nil {
+			continue
+		}
+		for _, obj := range items {
+			if o, ok := obj.(runtime.Object); ok {
+				objects = append(objects, o)
+			}
+		}
+	}
+	return objects
+}
+
+// Get returns the cached object of the kind with the given name.
+func (c *Cache) Get(kind string, namespace string, name string) (runtime.Object, bool) {
+	for _, indexer := range c.byKind[kind] {
+		obj, exists, err := indexer.GetByKey(namespace + "/" + name)
+		if err != nil || !exists {
+			continue
+		}
+		o, ok := obj.(runtime.Object)
+		return o, ok
+	}
+	return nil, false
+}
+
+// Watch returns a watch over all changes to the cache. The watch must be
+// stopped when it is no longer needed. It ends early when the cache is
+// released or the subscriber falls too far behind the changes. Caches that
+// never change return a watch without events.
+func (c *Cache) Watch() watch.Interface {
+	c.notifyMutex.Lock()
+	defer c.notifyMutex.Unlock()
+	if c.subscribers == nil || c.stopped {
+		return watch.NewEmptyWatch()
+	}
+	w := &cacheWatch{cache: c, result: make(chan watch.Event, cacheQueueLength)}
+	c.subscribers[w] = true
+	return w
+}
+
+// cacheWatch is the watch of a subscriber of a cache with its own buffer.
+type cacheWatch struct {
+	cache  *Cache
+	result chan watch.Event
+}
+
+// Stop ends the watch.
+func (w *cacheWatch) Stop() {
+	w.cache.notifyMutex.Lock()
+	defer w.cache.notifyMutex.Unlock()
+	w.cache.unsubscribe(w)
+}
+
+// ResultChan returns the channel of the changes, which is closed when the
+// watch ends.
+func (w *cacheWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func cacheIndexers() cache.Indexers {
+	return cache.Indexers{
+		AppNameIndex:   appNameIndexFunc,
+		OwnerIndex:     ownerIndexFunc,
+		NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	}
+}
+
+func appNameIndexFunc(obj interface{}) ([]string, error) {
+	o, ok := obj.(v1.Object)
+	if !ok {
+		return []string{}, nil
+	}
+	if name, ok := o.GetLabels()["app.kubernetes.io/name"]; ok {
+		return []string{AppNameKey(o.GetNamespace(), name)}, nil
+	}
+	return []string{}, nil
+}
+
+// AppNameKey returns the value of the AppNameIndex for the objects of the
+// namespace with the given app.kubernetes.io/name label. Objects of different
+// namespaces never share an application name.
+func AppNameKey(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+func ownerIndexFunc(obj interface{}) ([]string, error) {
+	o, ok := obj.(v1.Object)
+	if !ok {
+		return []string{}, nil
+	}
+	if ref := v1.GetControllerOf(o); ref != nil {
+		return []string{string(ref.UID)}, nil
+	}
+	return []string{}, nil
+}
@@ -0,0 +1,249 @@
+package kubeclient_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCacheRegistry_Acquire(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/name": "nodejs"}
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: labels},
+	}
+	other := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "myproject"},
+	}
+	k := test.FakeKubeClient(dc, service, other)
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+
+	c, err := registry.Acquire(k, kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+
+	t.Run("list", func(t *testing.T) {
+		require.Len(t, c.List(), 3)
+	})
+
+	t.Run("by index", func(t *testing.T) {
+		require.Len(t, c.ByIndex(kubeclient.AppNameIndex, kubeclient.AppNameKey("myproject", "nodejs")), 2)
+		require.Empty(t, c.ByIndex(kubeclient.AppNameIndex, kubeclient.AppNameKey("otherproject", "nodejs")))
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		other, err := registry.Acquire(test.FakeKubeClient(), kubeclient.Filter{}, "myproject")
+		require.NoError(t, err)
+		defer registry.Release(other)
+		require.True(t, c == other)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		w := c.Watch()
+		defer w.Stop()
+		created := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "myproject"},
+		}
+		_, err := k.CoreClient.CoreV1().Services("myproject").Create(created)
+		require.NoError(t, err)
+		select {
+		case event := <-w.ResultChan():
+			require.Equal(t, watch.Added, event.Type)
+			require.Equal(t, "created", event.Object.(*corev1.Service).Name)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the change notification")
+		}
+	})
+}
+
+func TestCacheRegistry_SlowSubscriber(t *testing.T) {
+	k := test.FakeKubeClient()
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(k, kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+
+	slow := c.Watch()
+	defer slow.Stop()
+	fast := c.Watch()
+	defer fast.Stop()
+	const changes = 1500
+
+	// The subscriber that keeps up gets all changes although the other one
+	// does not read any. The changes are made one at a time since the fake
+	// clientset only buffers a few of them.
+	for i := 0; i < changes; i++ {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("service-%d", i), Namespace: "myproject"}}
+		_, err := k.CoreClient.CoreV1().Services("myproject").Create(service)
+		require.NoError(t, err)
+		select {
+		case event, ok := <-fast.ResultChan():
+			require.True(t, ok)
+			require.Equal(t, service.Name, event.Object.(*corev1.Service).Name)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the change notification")
+		}
+	}
+
+	// The watch of the subscriber that fell behind ends after its buffer.
+	n := 0
+	for range slow.ResultChan() {
+		n++
+	}
+	require.True(t, n < changes, "the slow subscriber got all %d changes", n)
+}
+
+func TestCacheRegistry_Release(t *testing.T) {
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+	c, err := registry.Acquire(test.FakeKubeClient(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	registry.Release(c)
+
+	// Once released, a new cache is created for the namespace.
+	other, err := registry.Acquire(test.FakeKubeClient(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(other)
+	require.False(t, c == other)
+}
+
+func TestCacheRegistry_AcquireNamespaces(t *testing.T) {
+	var objects []runtime.Object
+	for _, namespace := range []string{"dev", "stage", "prod"} {
+		objects = append(objects, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nodejs",
+				Namespace: namespace,
+				Labels:    map[string]string{"app.kubernetes.io/name": "nodejs"},
+			},
+		})
+	}
+	k := test.FakeKubeClient(objects...)
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+
+	c, err := registry.Acquire(k, kubeclient.Filter{}, "stage", "dev", "stage")
+	require.NoError(t, err)
+	defer registry.Release(c)
+	require.Equal(t, []string{"dev", "stage"}, c.Namespaces())
+	require.Len(t, c.List(), 2)
+	require.Len(t, c.ByIndex(kubeclient.AppNameIndex, kubeclient.AppNameKey("dev", "nodejs")), 1)
+	_, ok := c.Get("Service", "stage", "nodejs")
+	require.True(t, ok)
+	_, ok = c.Get("Service", "prod", "nodejs")
+	require.False(t, ok)
+
+	// The order of the namespaces does not matter.
+	other, err := registry.Acquire(k, kubeclient.Filter{}, "dev", "stage")
+	require.NoError(t, err)
+	defer registry.Release(other)
+	require.True(t, c == other)
+
+	// All namespaces cover any other namespace.
+	all, err := registry.Acquire(k, kubeclient.Filter{}, "dev", metav1.NamespaceAll)
+	require.NoError(t, err)
+	defer registry.Release(all)
+	require.Equal(t, []string{metav1.NamespaceAll}, all.Namespaces())
+	require.Len(t, all.List(), 3)
+}
+
+func TestCacheRegistry_AcquireFilter(t *testing.T) {
+	var objects []runtime.Object
+	for _, name := range []string{"nodejs", "perl"} {
+		labels := map[string]string{"app.kubernetes.io/name": name}
+		objects = append(objects,
+			&deploymentconfigv1.DeploymentConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", Labels: labels},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", Labels: labels},
+			},
+		)
+	}
+	k := test.FakeKubeClient(objects...)
+	registry := kubeclient.NewCacheRegistry(kubeclient.NewDefaultKindRegistry(), 0, 10*time.Second)
+
+	// The filter only selects the nodes, the services are cached anyway.
+	filter := kubeclient.Filter{LabelSelector: "app.kubernetes.io/name=nodejs", Kinds: []string{"DeploymentConfig"}}
+	c, err := registry.Acquire(k, filter, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(c)
+	require.Len(t, c.ListKind("DeploymentConfig"), 1)
+	require.Len(t, c.ListKind("Service"), 2)
+	require.Equal(t, "myproject?kinds=DeploymentConfig&labelSelector=app.kubernetes.io%2Fname%3Dnodejs", c.Scope())
+
+	// Excluded kinds are never cached.
+	excluded, err := registry.Acquire(k, kubeclient.Filter{ExcludedKinds: []string{"Service"}}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(excluded)
+	require.Empty(t, excluded.ListKind("Service"))
+
+	// Caches of other filters are not shared.
+	all, err := registry.Acquire(k, kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	defer registry.Release(all)
+	require.False(t, c == all)
+	require.Len(t, all.List(), 4)
+}
+
+func TestListCache(t *testing.T) {
+	dc := &deploymentconfigv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", UID: "1"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "nodejs-1-abcde",
+			Namespace:       "myproject",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(dc, deploymentconfigv1.GroupVersion.WithKind("DeploymentConfig"))},
+		},
+	}
+	c, err := kubeclient.ListCache(test.FakeKubeClient(dc, pod), kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+
+	require.Len(t, c.List(), 2)
+
+	owned := c.ByIndex(kubeclient.OwnerIndex, "1")
+	require.Len(t, owned, 1)
+	require.Equal(t, "nodejs-1-abcde", owned[0].(*corev1.Pod).Name)
+
+	obj, ok := c.Get("DeploymentConfig", "myproject", "nodejs")
+	require.True(t, ok)
+	require.Equal(t, dc.Name, obj.(*deploymentconfigv1.DeploymentConfig).Name)
+	_, ok = c.Get("DeploymentConfig", "myproject", "other")
+	require.False(t, ok)
+	_, ok = c.Get("Unknown", "myproject", "nodejs")
+	require.False(t, ok)
+
+	require.Len(t, c.ListKindInNamespace("Pod", "myproject"), 1)
+	require.Empty(t, c.ListKindInNamespace("Pod", "stage"))
+
+	// The cache never changes.
+	w := c.Watch()
+	defer w.Stop()
+	_, open := <-w.ResultChan()
+	require.False(t, open)
+}
+
+func TestListCache_UnavailableDynamicKinds(t *testing.T) {
+	k := test.FakeKubeClient()
+	k.DynamicClient.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+
+	// The cluster does not serve Knative, which is no reason to fail.
+	c, err := kubeclient.ListCache(k, kubeclient.NewDefaultKindRegistry(), kubeclient.Filter{}, "myproject")
+	require.NoError(t, err)
+	require.Empty(t, c.List())
+}
@@ -1,52 +1,238 @@
 package kubeclient
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
 	ocappsclient "github.com/openshift/client-go/apps/clientset/versioned/typed/apps/v1"
+	ocbuildclient "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
+	ocimageclient "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1"
 	ocrouteclient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	errs "github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 type KubeClient struct {
 	CoreClient    kubernetes.Interface
 	OcAppsClient  ocappsclient.AppsV1Interface
 	OcRouteClient ocrouteclient.RouteV1Interface
+	OcBuildClient ocbuildclient.BuildV1Interface
+	OcImageClient ocimageclient.ImageV1Interface
+	DynamicClient dynamic.Interface
+
+	// identity distinguishes clients with different credentials without
+	// revealing them.
+	identity string
+}
+
+// TLSOptions are the settings used to verify the API server and to present a
+// client certificate to it. Empty values keep the settings of the config they
+// are applied to.
+type TLSOptions struct {
+	// CAFile is the path of the CA bundle that signed the API server
+	// certificate.
+	CAFile string
+	// CertFile and KeyFile are the paths of the client certificate and key.
+	CertFile string
+	KeyFile  string
+	// Insecure turns off the verification of the API server certificate. It
+	// should only ever be used for development.
+	Insecure bool
+}
+
+// NewKubeClient creates a client that talks to the API server at host on
+// behalf of the owner of the bearer token.
+func NewKubeClient(host string, bearerToken string, tls TLSOptions) (*KubeClient, error) {
+	if bearerToken == "" {
+		return nil, errs.New("missing bearer token")
+	}
+	return NewKubeClientForConfig(TokenConfig(host, bearerToken, tls))
 }
 
-func NewKubeClient(parameters []string) *KubeClient {
+// NewKubeClientForConfig creates a client from a REST config.
+func NewKubeClientForConfig(config *rest.Config) (*KubeClient, error) {
+	if config == nil {
+		return nil, errs.New("missing client config")
+	}
+	if err := validateHost(config.Host); err != nil {
+		return nil, err
+	}
 	var err error
 	kc := new(KubeClient)
-	config := getKubeConfig(parameters)
-	kc.CoreClient, err = kubernetes.NewForConfig(&config)
+	kc.CoreClient, err = kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, errs.Wrap(err, "failed to create the kubernetes client")
 	}
-	kc.OcAppsClient, err = ocappsclient.NewForConfig(&config)
+	kc.OcAppsClient, err = ocappsclient.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, errs.Wrap(err, "failed to create the openshift apps client")
 	}
 
-	kc.OcRouteClient, err = ocrouteclient.NewForConfig(&config)
+	kc.OcRouteClient, err = ocrouteclient.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		return nil, errs.Wrap(err, "failed to create the openshift route client")
 	}
 
-	return kc
+	kc.OcBuildClient, err = ocbuildclient.NewForConfig(config)
+	if err != nil {
+		return nil, errs.Wrap(err, "failed to create the openshift build client")
+	}
+
+	kc.OcImageClient, err = ocimageclient.NewForConfig(config)
+	if err != nil {
+		return nil, errs.Wrap(err, "failed to create the openshift image client")
+	}
+
+	kc.DynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errs.Wrap(err, "failed to create the dynamic client")
+	}
+
+	kc.identity = getIdentity(config)
+
+	return kc, nil
 }
 
-func getKubeConfig(parameters []string) rest.Config {
-	host := parameters[0] + "://" + parameters[1] + ":" + parameters[2]
-	bearerToken := parameters[3]
+// NewInClusterKubeClient creates a client that acts with the service account
+// of the pod the service runs in.
+func NewInClusterKubeClient(tls TLSOptions) (*KubeClient, error) {
+	config, err := InClusterConfig(tls)
+	if err != nil {
+		return nil, err
+	}
+	return NewKubeClientForConfig(config)
+}
 
-	return getOpenshiftAPIConfig(host, bearerToken)
+// NewKubeClientFromKubeconfig creates a client from a kubeconfig file using
+// the given context. An empty path falls back to $KUBECONFIG and
+// ~/.kube/config, an empty context to the current context of the file.
+func NewKubeClientFromKubeconfig(path string, context string, tls TLSOptions) (*KubeClient, error) {
+	config, err := KubeconfigConfig(path, context, tls)
+	if err != nil {
+		return nil, err
+	}
+	return NewKubeClientForConfig(config)
 }
 
-func getOpenshiftAPIConfig(host string, bearerToken string) rest.Config {
-	return rest.Config{
+// TokenConfig returns the config for the API server at host that
+// authenticates with the bearer token.
+func TokenConfig(host string, bearerToken string, tls TLSOptions) *rest.Config {
+	config := &rest.Config{
 		Host:        host,
 		BearerToken: bearerToken,
-		TLSClientConfig: rest.TLSClientConfig{
-			Insecure: true,
-		},
 	}
+	applyTLSOptions(config, tls)
+	return config
+}
+
+// InClusterConfig returns the config for the service account of the pod the
+// service runs in.
+func InClusterConfig(tls TLSOptions) (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, errs.Wrap(err, "failed to load the in-cluster config")
+	}
+	applyTLSOptions(config, tls)
+	return config, nil
+}
+
+// KubeconfigConfig returns the config of a context of a kubeconfig file.
+func KubeconfigConfig(path string, context string, tls TLSOptions) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = path
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to load context %q of kubeconfig %q", context, path)
+	}
+	applyTLSOptions(config, tls)
+	return config, nil
+}
+
+// WithBearerToken returns a copy of the config that authenticates with the
+// bearer token instead of the credentials of the config.
+func WithBearerToken(config *rest.Config, bearerToken string) *rest.Config {
+	c := rest.CopyConfig(config)
+	c.BearerToken = bearerToken
+	c.BearerTokenFile = ""
+	c.Username = ""
+	c.Password = ""
+	c.AuthProvider = nil
+	c.ExecProvider = nil
+	c.Impersonate = rest.ImpersonationConfig{}
+	// A client certificate would authenticate the request before the
+	// token is looked at.
+	c.TLSClientConfig.CertFile = ""
+	c.TLSClientConfig.CertData = nil
+	c.TLSClientConfig.KeyFile = ""
+	c.TLSClientConfig.KeyData = nil
+	return c
+}
+
+// Identity returns an opaque value that is equal for all clients created
+// with the same API server and credentials.
+func (kc KubeClient) Identity() string {
+	return kc.identity
+}
+
+// Checks that host is the http or https URL of an API server. Like the REST
+// client it accepts a bare host and port, which defaults to https.
+func validateHost(host string) error {
+	if host == "" {
+		return errs.New("missing API server URL")
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return errs.Wrapf(err, "invalid API server URL %q", host)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errs.Errorf("invalid API server URL %q: scheme must be http or https", host)
+	}
+	if u.Host == "" {
+		return errs.Errorf("invalid API server URL %q: missing host", host)
+	}
+	return nil
+}
+
+func applyTLSOptions(config *rest.Config, tls TLSOptions) {
+	if tls.CAFile != "" {
+		config.TLSClientConfig.CAFile = tls.CAFile
+		config.TLSClientConfig.CAData = nil
+	}
+	if tls.CertFile != "" {
+		config.TLSClientConfig.CertFile = tls.CertFile
+		config.TLSClientConfig.CertData = nil
+	}
+	if tls.KeyFile != "" {
+		config.TLSClientConfig.KeyFile = tls.KeyFile
+		config.TLSClientConfig.KeyData = nil
+	}
+	if tls.Insecure {
+		config.TLSClientConfig.Insecure = true
+	}
+}
+
+func getIdentity(config *rest.Config) string {
+	h := sha256.New()
+	for _, v := range []string{
+		config.Host,
+		config.BearerToken,
+		config.BearerTokenFile,
+		config.Username,
+		config.TLSClientConfig.CertFile,
+		string(config.TLSClientConfig.CertData),
+		config.Impersonate.UserName,
+	} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -1,9 +1,13 @@
 package kubeclient
 
 import (
+	"io/ioutil"
+	"os"
+
 	ocfakeappsclient "github.com/openshift/client-go/apps/clientset/versioned/fake"
 	ocfakerouteclient "github.com/openshift/client-go/route/clientset/versioned/fake"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/client-go/kubernetes/fake"
 	"testing"
 )
@@ -16,3 +20,128 @@ func TestNewKubeClient(t *testing.T) {
 	assert.NotNil(t, k.CoreClient, "Kubecore client shouldn't be nil")
 }
 
+func TestKubeClient_Identity(t *testing.T) {
+	a, err := NewKubeClient("https://api.example.com:6443", "token-a", TLSOptions{})
+	require.NoError(t, err)
+	b, err := NewKubeClient("https://api.example.com:6443", "token-b", TLSOptions{})
+	require.NoError(t, err)
+	c, err := NewKubeClient("https://api.example.com:6443", "token-a", TLSOptions{})
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Identity(), b.Identity())
+	assert.Equal(t, a.Identity(), c.Identity())
+	assert.NotContains(t, a.Identity(), "token-a")
+}
+
+func TestNewKubeClient_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		host  string
+		token string
+	}{
+		{"missing host", "", "token"},
+		{"missing token", "https://api.example.com:6443", ""},
+		{"unsupported scheme", "ftp://api.example.com", "token"},
+		{"missing hostname", "https://", "token"},
+		{"malformed host", "https://api example com:port", "token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKubeClient(tt.host, tt.token, TLSOptions{})
+			require.Error(t, err)
+			require.Nil(t, k)
+		})
+	}
+
+	t.Run("bare host defaults to https", func(t *testing.T) {
+		k, err := NewKubeClient("api.example.com:6443", "token", TLSOptions{})
+		require.NoError(t, err)
+		require.NotNil(t, k)
+	})
+
+	t.Run("missing config", func(t *testing.T) {
+		k, err := NewKubeClientForConfig(nil)
+		require.Error(t, err)
+		require.Nil(t, k)
+	})
+}
+
+func TestTokenConfig(t *testing.T) {
+	t.Run("verifies the API server by default", func(t *testing.T) {
+		config := TokenConfig("https://api.example.com:6443", "token", TLSOptions{})
+		assert.Equal(t, "https://api.example.com:6443", config.Host)
+		assert.Equal(t, "token", config.BearerToken)
+		assert.False(t, config.TLSClientConfig.Insecure)
+	})
+	t.Run("tls options", func(t *testing.T) {
+		config := TokenConfig("https://api.example.com:6443", "token", TLSOptions{CAFile: "ca.crt", CertFile: "tls.crt", KeyFile: "tls.key"})
+		assert.Equal(t, "ca.crt", config.TLSClientConfig.CAFile)
+		assert.Equal(t, "tls.crt", config.TLSClientConfig.CertFile)
+		assert.Equal(t, "tls.key", config.TLSClientConfig.KeyFile)
+	})
+}
+
+func TestWithBearerToken(t *testing.T) {
+	config := TokenConfig("https://api.example.com:6443", "service-token", TLSOptions{CAFile: "ca.crt", CertFile: "tls.crt", KeyFile: "tls.key"})
+	config.Username = "admin"
+
+	c := WithBearerToken(config, "user-token")
+	assert.Equal(t, "user-token", c.BearerToken)
+	assert.Equal(t, "", c.Username)
+	assert.Equal(t, "", c.TLSClientConfig.CertFile)
+	assert.Equal(t, "", c.TLSClientConfig.KeyFile)
+	assert.Equal(t, "ca.crt", c.TLSClientConfig.CAFile)
+
+	// The original config is left untouched.
+	assert.Equal(t, "service-token", config.BearerToken)
+	assert.Equal(t, "tls.crt", config.TLSClientConfig.CertFile)
+}
+
+func TestKubeconfigConfig(t *testing.T) {
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+- name: stage
+  cluster:
+    server: https://stage.example.com:6443
+users:
+- name: developer
+  user:
+    token: dev-token
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: developer
+- name: stage
+  context:
+    cluster: stage
+    user: developer
+current-context: dev
+`
+	tmpFile, err := ioutil.TempFile(os.TempDir(), "kubeconfig-")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.Write([]byte(kubeconfig))
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	t.Run("current context", func(t *testing.T) {
+		config, err := KubeconfigConfig(tmpFile.Name(), "", TLSOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://dev.example.com:6443", config.Host)
+		assert.Equal(t, "dev-token", config.BearerToken)
+	})
+	t.Run("explicit context", func(t *testing.T) {
+		config, err := KubeconfigConfig(tmpFile.Name(), "stage", TLSOptions{CAFile: "ca.crt"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://stage.example.com:6443", config.Host)
+		assert.Equal(t, "ca.crt", config.TLSClientConfig.CAFile)
+	})
+	t.Run("unknown context", func(t *testing.T) {
+		_, err := KubeconfigConfig(tmpFile.Name(), "prod", TLSOptions{})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package kubeclient
+
+import (
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func (kc KubeClient) ListDeployments(namespace string, options v1.ListOptions) (*appsv1.DeploymentList, error) {
+	return kc.CoreClient.AppsV1().Deployments(namespace).List(options)
+}
+
+func (kc KubeClient) ListDeploymentConfigs(namespace string, options v1.ListOptions) (*deploymentconfigv1.DeploymentConfigList, error) {
+	return kc.OcAppsClient.DeploymentConfigs(namespace).List(options)
+}
+
+func (kc KubeClient) ListReplicationControllers(namespace string, options v1.ListOptions) (*corev1.ReplicationControllerList, error) {
+	return kc.CoreClient.CoreV1().ReplicationControllers(namespace).List(options)
+}
+
+func (kc KubeClient) ListReplicaSets(namespace string, options v1.ListOptions) (*appsv1.ReplicaSetList, error) {
+	return kc.CoreClient.AppsV1().ReplicaSets(namespace).List(options)
+}
+
+func (kc KubeClient) ListPods(namespace string, options v1.ListOptions) (*corev1.PodList, error) {
+	return kc.CoreClient.CoreV1().Pods(namespace).List(options)
+}
+
+func (kc KubeClient) ListRoutes(namespace string, options v1.ListOptions) (*routev1.RouteList, error) {
+	return kc.OcRouteClient.Routes(namespace).List(options)
+}
+
+func (kc KubeClient) ListServices(namespace string, options v1.ListOptions) (*corev1.ServiceList, error) {
+	return kc.CoreClient.CoreV1().Services(namespace).List(options)
+}
+
+func (kc KubeClient) ListIngresses(namespace string, options v1.ListOptions) (*extensionsv1beta1.IngressList, error) {
+	return kc.CoreClient.ExtensionsV1beta1().Ingresses(namespace).List(options)
+}
+
+func (kc KubeClient) ListBuildConfigs(namespace string, options v1.ListOptions) (*buildv1.BuildConfigList, error) {
+	return kc.OcBuildClient.BuildConfigs(namespace).List(options)
+}
+
+func (kc KubeClient) ListBuilds(namespace string, options v1.ListOptions) (*buildv1.BuildList, error) {
+	return kc.OcBuildClient.Builds(namespace).List(options)
+}
+
+func (kc KubeClient) ListImageStreams(namespace string, options v1.ListOptions) (*imagev1.ImageStreamList, error) {
+	return kc.OcImageClient.ImageStreams(namespace).List(options)
+}
+
+func (kc KubeClient) ListStatefulSets(namespace string, options v1.ListOptions) (*appsv1.StatefulSetList, error) {
+	return kc.CoreClient.AppsV1().StatefulSets(namespace).List(options)
+}
+
+func (kc KubeClient) ListDaemonSets(namespace string, options v1.ListOptions) (*appsv1.DaemonSetList, error) {
+	return kc.CoreClient.AppsV1().DaemonSets(namespace).List(options)
+}
+
+func (kc KubeClient) ListCronJobs(namespace string, options v1.ListOptions) (*batchv1beta1.CronJobList, error) {
+	return kc.CoreClient.BatchV1beta1().CronJobs(namespace).List(options)
+}
+
+func (kc KubeClient) ListJobs(namespace string, options v1.ListOptions) (*batchv1.JobList, error) {
+	return kc.CoreClient.BatchV1().Jobs(namespace).List(options)
+}
+
+func (kc KubeClient) ListDynamic(resource schema.GroupVersionResource, namespace string, options v1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return kc.DynamicClient.Resource(resource).Namespace(namespace).List(options)
+}
@@ -0,0 +1,150 @@
+package kubeclient
+
+import (
+	"net/url"
+	"strings"
+
+	errs "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Filter narrows the nodes of a cache. The selectors and kinds only select
+// the objects of node kinds, while the resources of the selected nodes, such
+// as their pods, services and routes, are cached whether they match or not.
+// The API server applies the selectors to the kinds whose objects are always
+// nodes. Objects of owned node kinds may be resources of other nodes, so they
+// are cached unfiltered and Selects tells whether they are nodes.
+type Filter struct {
+	// LabelSelector selects the nodes by their labels.
+	LabelSelector string
+	// FieldSelector selects the nodes by their fields. Only the fields of
+	// Kind.FieldsOf can be selected.
+	FieldSelector string
+	// Kinds are the names of the node kinds to cache. All node kinds of the
+	// registry are cached if it is empty.
+	Kinds []string
+	// ExcludedKinds are the names of the kinds that are never cached, such
+	// as the optional kinds that the caller of a shared cache may not list.
+	ExcludedKinds []string
+}
+
+// String returns the filter in a form that is the same for equal filters as
+// long as their selectors and kinds are given in the same order.
+func (f Filter) String() string {
+	values := url.Values{}
+	if f.LabelSelector != "" {
+		values.Set("labelSelector", f.LabelSelector)
+	}
+	if f.FieldSelector != "" {
+		values.Set("fieldSelector", f.FieldSelector)
+	}
+	if len(f.Kinds) > 0 {
+		values.Set("kinds", strings.Join(f.Kinds, ","))
+	}
+	if len(f.ExcludedKinds) > 0 {
+		values.Set("excludedKinds", strings.Join(f.ExcludedKinds, ","))
+	}
+	return values.Encode()
+}
+
+// Validate checks that the selectors of the filter parse and that the field
+// selector only selects fields of the node kinds that the filter selects, so
+// that the API server does not reject the lists of the cache.
+func (f Filter) Validate(kinds *KindRegistry) error {
+	if _, err := labels.Parse(f.LabelSelector); err != nil {
+		return errs.Wrapf(err, "invalid label selector %q", f.LabelSelector)
+	}
+	selector, err := fields.ParseSelector(f.FieldSelector)
+	if err != nil {
+		return errs.Wrapf(err, "invalid field selector %q", f.FieldSelector)
+	}
+	for _, k := range f.kindsOf(kinds) {
+		if !k.Node || (len(f.Kinds) > 0 && !containsString(f.Kinds, k.Name)) {
+			continue
+		}
+		selectable := k.FieldsOf(k.Object)
+		for _, requirement := range selector.Requirements() {
+			if !selectable.Has(requirement.Field) {
+				return errs.Errorf("field %q cannot select objects of kind %s", requirement.Field, k.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Selects tells whether the object of the kind is selected by the filter.
+// Objects of other than node kinds are always selected.
+func (f Filter) Selects(k Kind, obj runtime.Object) bool {
+	if !k.Node {
+		return true
+	}
+	if len(f.Kinds) > 0 && !containsString(f.Kinds, k.Name) {
+		return false
+	}
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	// The filter is validated before it is used.
+	labelSelector, err := labels.Parse(f.LabelSelector)
+	if err != nil || !labelSelector.Matches(labels.Set(o.GetLabels())) {
+		return false
+	}
+	fieldSelector, err := fields.ParseSelector(f.FieldSelector)
+	return err == nil && fieldSelector.Matches(k.FieldsOf(obj))
+}
+
+// Gets the kinds of the registry that the filter selects. Kinds that are not
+// nodes or may be owned by other nodes are always selected unless they are
+// excluded.
+func (f Filter) kindsOf(kinds *KindRegistry) []Kind {
+	var result []Kind
+	for _, k := range kinds.Kinds() {
+		if containsString(f.ExcludedKinds, k.Name) {
+			continue
+		}
+		if len(f.Kinds) > 0 && k.Node && !k.Owned && !containsString(f.Kinds, k.Name) {
+			continue
+		}
+		result = append(result, k)
+	}
+	return result
+}
+
+// Wraps the lister and watcher of the kind so that it only lists and watches
+// the objects that match the selectors of the filter. Only kinds whose
+// objects are always nodes are filtered by the API server.
+func (f Filter) listWatch(k Kind, lw cache.ListerWatcher) cache.ListerWatcher {
+	if !k.Node || k.Owned || (f.LabelSelector == "" && f.FieldSelector == "") {
+		return lw
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+			return lw.List(f.apply(options))
+		},
+		WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+			return lw.Watch(f.apply(options))
+		},
+	}
+}
+
+func (f Filter) apply(options v1.ListOptions) v1.ListOptions {
+	options.LabelSelector = f.LabelSelector
+	options.FieldSelector = f.FieldSelector
+	return options
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
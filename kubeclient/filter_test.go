@@ -0,0 +1,68 @@
+package kubeclient_test
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilter_Validate(t *testing.T) {
+	kinds := kubeclient.NewDefaultKindRegistry()
+	tests := []struct {
+		filter kubeclient.Filter
+		valid  bool
+	}{
+		{kubeclient.Filter{}, true},
+		{kubeclient.Filter{LabelSelector: "app in (web"}, false},
+		{kubeclient.Filter{FieldSelector: "metadata.name"}, false},
+		{kubeclient.Filter{FieldSelector: "metadata.name=nodejs,metadata.namespace!=stage"}, true},
+		// Only jobs can be selected by their successful pods.
+		{kubeclient.Filter{FieldSelector: "status.successful=1"}, false},
+		{kubeclient.Filter{FieldSelector: "status.successful=1", Kinds: []string{"Job"}}, true},
+		// Fields of resources cannot select nodes.
+		{kubeclient.Filter{FieldSelector: "status.phase=Running"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter.String(), func(t *testing.T) {
+			err := tt.filter.Validate(kinds)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestFilter_Selects(t *testing.T) {
+	kinds := kubeclient.NewDefaultKindRegistry()
+	kindOf := func(name string) kubeclient.Kind {
+		k, ok := kinds.Lookup(name)
+		require.True(t, ok)
+		return k
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject", Labels: map[string]string{"app": "web"}},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "myproject"},
+		Status:     batchv1.JobStatus{Succeeded: 1},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nodejs-1", Namespace: "myproject"}}
+
+	filter := kubeclient.Filter{LabelSelector: "app=web", Kinds: []string{"Deployment"}}
+	require.True(t, filter.Selects(kindOf("Deployment"), deployment))
+	require.False(t, filter.Selects(kindOf("Job"), job))
+	// Resources are selected whether they match or not.
+	require.True(t, filter.Selects(kindOf("Pod"), pod))
+
+	filter = kubeclient.Filter{FieldSelector: "status.successful=1"}
+	require.True(t, filter.Selects(kindOf("Job"), job))
+	filter = kubeclient.Filter{FieldSelector: "metadata.name!=nodejs"}
+	require.False(t, filter.Selects(kindOf("Deployment"), deployment))
+}
@@ -0,0 +1,651 @@
+package kubeclient
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	routev1 "github.com/openshift/api/route/v1"
+	errs "github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Kind describes a kind of resource that is part of the topology: how to
+// list and watch it, whether its objects are nodes of the topology or
+// resources of a node, and how to extract their metadata and status.
+type Kind struct {
+	// Name is the kind of the objects, e.g. "Deployment".
+	Name string
+	// Resource is the group, version and resource of the kind.
+	Resource schema.GroupVersionResource
+	// Object is an empty object of the kind. Objects are matched to their
+	// kind by its type.
+	Object runtime.Object
+	// Node tells whether the objects are nodes of the topology. Objects of
+	// other kinds are attached to the nodes as resources.
+	Node bool
+	// NodeType is the type of the nodes of the kind, e.g. "workload".
+	NodeType string
+	// Owned tells whether the objects are attached to the node that controls
+	// them through a chain of controller owner references instead of by
+	// their app.kubernetes.io/name label. Objects of kinds that are nodes
+	// and owned are only nodes if no node controls them.
+	Owned bool
+	// Optional tells whether the kind may be missing from the cluster or
+	// forbidden to the client. Such kinds are listed as empty then.
+	Optional bool
+	// ListWatch returns how to list and watch the objects of a namespace
+	// with the given client.
+	ListWatch func(kc *KubeClient, namespace string) cache.ListerWatcher
+	// Metadata returns the metadata of an object. The object metadata is
+	// used if it is nil.
+	Metadata func(obj runtime.Object) interface{}
+	// Status returns the status of an object.
+	Status func(obj runtime.Object) interface{}
+	// Spec returns the spec of an object.
+	Spec func(obj runtime.Object) interface{}
+	// PodTemplate returns the template of the pods of an object. It is nil
+	// for kinds without pods.
+	PodTemplate func(obj runtime.Object) *corev1.PodTemplateSpec
+	// Fields returns the fields of an object that the API server can select
+	// the objects by. Only the name and namespace can be selected if it is
+	// nil.
+	Fields func(obj runtime.Object) fields.Set
+}
+
+// KindRegistry holds the kinds that make up the topology. It is safe for
+// concurrent use, but kinds should be registered before the first topology
+// is built since existing caches do not pick up new kinds.
+type KindRegistry struct {
+	mutex       sync.RWMutex
+	kinds       []Kind
+	byName      map[string]int
+	byType      map[reflect.Type]int
+	byGroupKind map[schema.GroupKind]int
+}
+
+// NewKindRegistry creates an empty registry.
+func NewKindRegistry() *KindRegistry {
+	return &KindRegistry{
+		byName:      make(map[string]int),
+		byType:      make(map[reflect.Type]int),
+		byGroupKind: make(map[schema.GroupKind]int),
+	}
+}
+
+// NewDefaultKindRegistry creates a registry with the built-in kinds.
+func NewDefaultKindRegistry() *KindRegistry {
+	r := NewKindRegistry()
+	for _, k := range defaultKinds() {
+		if err := r.Register(k); err != nil {
+			// The built-in kinds are known to be valid.
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds a kind to the registry.
+func (r *KindRegistry) Register(k Kind) error {
+	if k.Name == "" {
+		return errs.New("missing kind name")
+	}
+	if k.Object == nil {
+		return errs.Errorf("missing object of kind %q", k.Name)
+	}
+	if k.ListWatch == nil {
+		return errs.Errorf("missing list and watch function of kind %q", k.Name)
+	}
+	if k.Status == nil {
+		return errs.Errorf("missing status function of kind %q", k.Name)
+	}
+	u, unstructuredObject := k.Object.(*unstructured.Unstructured)
+	if unstructuredObject && u.GetKind() == "" {
+		return errs.Errorf("missing group, version and kind of the unstructured object of kind %q", k.Name)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.byName[k.Name]; ok {
+		return errs.Errorf("kind %q is already registered", k.Name)
+	}
+	gk := k.groupKind()
+	if _, ok := r.byGroupKind[gk]; ok {
+		return errs.Errorf("group kind %s is already registered", gk)
+	}
+	t := reflect.TypeOf(k.Object)
+	if _, ok := r.byType[t]; ok && !unstructuredObject {
+		return errs.Errorf("objects of type %s are already registered", t)
+	}
+	r.kinds = append(r.kinds, k)
+	r.byName[k.Name] = len(r.kinds) - 1
+	r.byGroupKind[gk] = len(r.kinds) - 1
+	if !unstructuredObject {
+		r.byType[t] = len(r.kinds) - 1
+	}
+	return nil
+}
+
+// Kinds returns all registered kinds in the order of their registration.
+func (r *KindRegistry) Kinds() []Kind {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]Kind(nil), r.kinds...)
+}
+
+// Lookup returns the kind with the given name.
+func (r *KindRegistry) Lookup(name string) (Kind, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	i, ok := r.byName[name]
+	if !ok {
+		return Kind{}, false
+	}
+	return r.kinds[i], true
+}
+
+// LookupGroupKind returns the kind with the given API group and kind, as
+// found in the owner references of objects.
+func (r *KindRegistry) LookupGroupKind(gk schema.GroupKind) (Kind, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	i, ok := r.byGroupKind[gk]
+	if !ok {
+		return Kind{}, false
+	}
+	return r.kinds[i], true
+}
+
+// KindOf returns the kind of the object. Typed objects are matched by their
+// type and unstructured objects by their group and kind.
+func (r *KindRegistry) KindOf(obj runtime.Object) (Kind, bool) {
+	if obj == nil {
+		return Kind{}, false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	var i int
+	var ok bool
+	if u, isUnstructured := obj.(*unstructured.Unstructured); isUnstructured {
+		i, ok = r.byGroupKind[u.GroupVersionKind().GroupKind()]
+	} else {
+		i, ok = r.byType[reflect.TypeOf(obj)]
+	}
+	if !ok {
+		return Kind{}, false
+	}
+	return r.kinds[i], true
+}
+
+// Gets the API group and kind of the objects of the kind. The name of the
+// kind is used for typed objects, which usually carry no type information.
+func (k Kind) groupKind() schema.GroupKind {
+	if u, ok := k.Object.(*unstructured.Unstructured); ok {
+		return u.GroupVersionKind().GroupKind()
+	}
+	return schema.GroupKind{Group: k.Resource.Group, Kind: k.Name}
+}
+
+// MetadataOf returns the metadata of an object of the kind.
+func (k Kind) MetadataOf(obj runtime.Object) interface{} {
+	if k.Metadata != nil {
+		return k.Metadata(obj)
+	}
+	if o, ok := obj.(v1.ObjectMetaAccessor); ok {
+		return o.GetObjectMeta()
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object["metadata"]
+	}
+	return nil
+}
+
+// FieldsOf returns the fields of an object of the kind that field selectors
+// can select.
+func (k Kind) FieldsOf(obj runtime.Object) fields.Set {
+	if k.Fields != nil {
+		return k.Fields(obj)
+	}
+	return objectMetaFields(obj)
+}
+
+// Gets the metadata fields that the objects of all kinds can be selected by.
+func objectMetaFields(obj runtime.Object) fields.Set {
+	set := fields.Set{"metadata.name": "", "metadata.namespace": ""}
+	if o, err := meta.Accessor(obj); err == nil {
+		set["metadata.name"] = o.GetName()
+		set["metadata.namespace"] = o.GetNamespace()
+	}
+	return set
+}
+
+// NewDynamicKind creates a kind whose objects are listed and watched as
+// unstructured objects with the dynamic client. The resource is guessed from
+// the kind. Namespaces in which the API is not served or may not be listed
+// have no objects of the kind, so that optional APIs such as Knative do not
+// keep caches from syncing.
+func NewDynamicKind(name string, gvk schema.GroupVersionKind, node bool, nodeType string) Kind {
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(gvk)
+	return Kind{
+		Name:     name,
+		Resource: resource,
+		Object:   object,
+		Node:     node,
+		NodeType: nodeType,
+		Optional: true,
+		ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+					list, err := kc.ListDynamic(resource, namespace, options)
+					if isUnavailable(err) {
+						return &unstructured.UnstructuredList{}, nil
+					}
+					return list, err
+				},
+				WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+					w, err := kc.DynamicClient.Resource(resource).Namespace(namespace).Watch(options)
+					if isUnavailable(err) {
+						// A watch without events that only ends when it is
+						// stopped.
+						return watch.NewFake(), nil
+					}
+					return w, err
+				},
+			}
+		},
+		Status: func(obj runtime.Object) interface{} {
+			return obj.(*unstructured.Unstructured).Object["status"]
+		},
+		Spec: func(obj runtime.Object) interface{} {
+			return obj.(*unstructured.Unstructured).Object["spec"]
+		},
+	}
+}
+
+// Checks whether the error tells that an API is not served or may not be
+// used.
+func isUnavailable(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsForbidden(err)
+}
+
+// knativeServingVersion is the API version of the Knative Serving kinds.
+var knativeServingVersion = schema.GroupVersion{Group: "serving.knative.dev", Version: "v1alpha1"}
+
+func defaultKinds() []Kind {
+	return []Kind{
+		{
+			Name:     "DeploymentConfig",
+			Resource: deploymentconfigv1.SchemeGroupVersion.WithResource("deploymentconfigs"),
+			Object:   &deploymentconfigv1.DeploymentConfig{},
+			Node:     true,
+			NodeType: "workload",
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListDeploymentConfigs(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.OcAppsClient.DeploymentConfigs(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*deploymentconfigv1.DeploymentConfig).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*deploymentconfigv1.DeploymentConfig).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return obj.(*deploymentconfigv1.DeploymentConfig).Spec.Template
+			},
+		},
+		{
+			Name:     "Deployment",
+			Resource: appsv1.SchemeGroupVersion.WithResource("deployments"),
+			Object:   &appsv1.Deployment{},
+			Node:     true,
+			NodeType: "workload",
+			Owned:    true,
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListDeployments(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.AppsV1().Deployments(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.Deployment).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.Deployment).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return &obj.(*appsv1.Deployment).Spec.Template
+			},
+		},
+		{
+			Name:     "StatefulSet",
+			Resource: appsv1.SchemeGroupVersion.WithResource("statefulsets"),
+			Object:   &appsv1.StatefulSet{},
+			Node:     true,
+			NodeType: "workload",
+			Owned:    true,
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListStatefulSets(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.AppsV1().StatefulSets(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.StatefulSet).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.StatefulSet).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return &obj.(*appsv1.StatefulSet).Spec.Template
+			},
+		},
+		{
+			Name:     "DaemonSet",
+			Resource: appsv1.SchemeGroupVersion.WithResource("daemonsets"),
+			Object:   &appsv1.DaemonSet{},
+			Node:     true,
+			NodeType: "workload",
+			Owned:    true,
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListDaemonSets(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.AppsV1().DaemonSets(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.DaemonSet).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.DaemonSet).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return &obj.(*appsv1.DaemonSet).Spec.Template
+			},
+		},
+		{
+			Name:     "CronJob",
+			Resource: batchv1beta1.SchemeGroupVersion.WithResource("cronjobs"),
+			Object:   &batchv1beta1.CronJob{},
+			Node:     true,
+			NodeType: "workload",
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListCronJobs(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.BatchV1beta1().CronJobs(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*batchv1beta1.CronJob).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*batchv1beta1.CronJob).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return &obj.(*batchv1beta1.CronJob).Spec.JobTemplate.Spec.Template
+			},
+		},
+		{
+			Name:     "Job",
+			Resource: batchv1.SchemeGroupVersion.WithResource("jobs"),
+			Object:   &batchv1.Job{},
+			Node:     true,
+			NodeType: "workload",
+			Owned:    true,
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListJobs(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.BatchV1().Jobs(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*batchv1.Job).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*batchv1.Job).Spec
+			},
+			PodTemplate: func(obj runtime.Object) *corev1.PodTemplateSpec {
+				return &obj.(*batchv1.Job).Spec.Template
+			},
+			Fields: func(obj runtime.Object) fields.Set {
+				set := objectMetaFields(obj)
+				set["status.successful"] = strconv.Itoa(int(obj.(*batchv1.Job).Status.Succeeded))
+				return set
+			},
+		},
+		NewDynamicKind("KnativeService", knativeServingVersion.WithKind("Service"), true, "knative-service"),
+		NewDynamicKind("Revision", knativeServingVersion.WithKind("Revision"), true, "knative-revision"),
+		{
+			Name:     "ReplicationController",
+			Resource: corev1.SchemeGroupVersion.WithResource("replicationcontrollers"),
+			Object:   &corev1.ReplicationController{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListReplicationControllers(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.CoreV1().ReplicationControllers(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.ReplicationController).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.ReplicationController).Spec
+			},
+		},
+		{
+			Name:     "ReplicaSet",
+			Resource: appsv1.SchemeGroupVersion.WithResource("replicasets"),
+			Object:   &appsv1.ReplicaSet{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListReplicaSets(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.AppsV1().ReplicaSets(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.ReplicaSet).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*appsv1.ReplicaSet).Spec
+			},
+		},
+		{
+			Name:     "Service",
+			Resource: corev1.SchemeGroupVersion.WithResource("services"),
+			Object:   &corev1.Service{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListServices(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.CoreV1().Services(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.Service).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.Service).Spec
+			},
+		},
+		{
+			Name:     "Route",
+			Resource: routev1.SchemeGroupVersion.WithResource("routes"),
+			Object:   &routev1.Route{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListRoutes(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.OcRouteClient.Routes(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*routev1.Route).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*routev1.Route).Spec
+			},
+		},
+		{
+			Name:     "Ingress",
+			Resource: extensionsv1beta1.SchemeGroupVersion.WithResource("ingresses"),
+			Object:   &extensionsv1beta1.Ingress{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListIngresses(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.ExtensionsV1beta1().Ingresses(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*extensionsv1beta1.Ingress).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*extensionsv1beta1.Ingress).Spec
+			},
+		},
+		{
+			Name:     "BuildConfig",
+			Resource: buildv1.SchemeGroupVersion.WithResource("buildconfigs"),
+			Object:   &buildv1.BuildConfig{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListBuildConfigs(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.OcBuildClient.BuildConfigs(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*buildv1.BuildConfig).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*buildv1.BuildConfig).Spec
+			},
+		},
+		{
+			Name:     "Build",
+			Resource: buildv1.SchemeGroupVersion.WithResource("builds"),
+			Object:   &buildv1.Build{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListBuilds(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.OcBuildClient.Builds(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*buildv1.Build).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*buildv1.Build).Spec
+			},
+		},
+		{
+			Name:     "ImageStream",
+			Resource: imagev1.SchemeGroupVersion.WithResource("imagestreams"),
+			Object:   &imagev1.ImageStream{},
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListImageStreams(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.OcImageClient.ImageStreams(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*imagev1.ImageStream).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*imagev1.ImageStream).Spec
+			},
+		},
+		{
+			Name:     "Pod",
+			Resource: corev1.SchemeGroupVersion.WithResource("pods"),
+			Object:   &corev1.Pod{},
+			Owned:    true,
+			ListWatch: func(kc *KubeClient, namespace string) cache.ListerWatcher {
+				return &cache.ListWatch{
+					ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+						return kc.ListPods(namespace, options)
+					},
+					WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+						return kc.CoreClient.CoreV1().Pods(namespace).Watch(options)
+					},
+				}
+			},
+			Status: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.Pod).Status
+			},
+			Spec: func(obj runtime.Object) interface{} {
+				return obj.(*corev1.Pod).Spec
+			},
+		},
+	}
+}
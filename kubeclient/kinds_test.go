@@ -0,0 +1,139 @@
+package kubeclient_test
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func persistentVolumeClaimKind() kubeclient.Kind {
+	return kubeclient.Kind{
+		Name:     "PersistentVolumeClaim",
+		Resource: corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims"),
+		Object:   &corev1.PersistentVolumeClaim{},
+		ListWatch: func(kc *kubeclient.KubeClient, namespace string) cache.ListerWatcher {
+			return &cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					return kc.CoreClient.CoreV1().PersistentVolumeClaims(namespace).List(options)
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return kc.CoreClient.CoreV1().PersistentVolumeClaims(namespace).Watch(options)
+				},
+			}
+		},
+		Status: func(obj runtime.Object) interface{} {
+			return obj.(*corev1.PersistentVolumeClaim).Status
+		},
+	}
+}
+
+func TestKindRegistry(t *testing.T) {
+	r := kubeclient.NewDefaultKindRegistry()
+
+	t.Run("built-in kinds", func(t *testing.T) {
+		var names []string
+		for _, k := range r.Kinds() {
+			names = append(names, k.Name)
+		}
+		require.Equal(t, []string{
+			"DeploymentConfig", "Deployment", "StatefulSet", "DaemonSet", "CronJob", "Job", "KnativeService", "Revision",
+			"ReplicationController", "ReplicaSet", "Service", "Route", "Ingress", "BuildConfig", "Build", "ImageStream", "Pod",
+		}, names)
+
+		k, ok := r.KindOf(&appsv1.Deployment{})
+		require.True(t, ok)
+		require.Equal(t, "Deployment", k.Name)
+		require.True(t, k.Node)
+
+		k, ok = r.Lookup("Service")
+		require.True(t, ok)
+		require.False(t, k.Node)
+
+		_, ok = r.KindOf(&corev1.PersistentVolumeClaim{})
+		require.False(t, ok)
+		_, ok = r.KindOf(nil)
+		require.False(t, ok)
+	})
+
+	t.Run("register", func(t *testing.T) {
+		require.NoError(t, r.Register(persistentVolumeClaimKind()))
+		k, ok := r.KindOf(&corev1.PersistentVolumeClaim{})
+		require.True(t, ok)
+		require.Equal(t, "PersistentVolumeClaim", k.Name)
+		require.Len(t, r.Kinds(), 18)
+	})
+
+	t.Run("dynamic kinds", func(t *testing.T) {
+		gvk := schema.GroupVersionKind{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}
+		require.NoError(t, r.Register(kubeclient.NewDynamicKind("EtcdCluster", gvk, true, "operator-backed")))
+
+		cluster := &unstructured.Unstructured{}
+		cluster.SetGroupVersionKind(gvk)
+		k, ok := r.KindOf(cluster)
+		require.True(t, ok)
+		require.Equal(t, "EtcdCluster", k.Name)
+		require.Equal(t, "etcdclusters", k.Resource.Resource)
+
+		// Unstructured objects are told apart by their group and kind.
+		service := &unstructured.Unstructured{}
+		service.SetAPIVersion("serving.knative.dev/v1alpha1")
+		service.SetKind("Service")
+		k, ok = r.KindOf(service)
+		require.True(t, ok)
+		require.Equal(t, "KnativeService", k.Name)
+		k, ok = r.LookupGroupKind(schema.GroupKind{Kind: "Service"})
+		require.True(t, ok)
+		require.Equal(t, "Service", k.Name)
+
+		require.Error(t, r.Register(kubeclient.NewDynamicKind("OtherEtcdCluster", gvk, true, "operator-backed")))
+		require.Error(t, r.Register(kubeclient.NewDynamicKind("Unknown", schema.GroupVersionKind{}, false, "")))
+	})
+
+	t.Run("invalid kinds", func(t *testing.T) {
+		duplicateName := persistentVolumeClaimKind()
+		duplicateName.Object = &corev1.ConfigMap{}
+		duplicateType := persistentVolumeClaimKind()
+		duplicateType.Name = "OtherPersistentVolumeClaim"
+		missingName := persistentVolumeClaimKind()
+		missingName.Name = ""
+		missingObject := persistentVolumeClaimKind()
+		missingObject.Object = nil
+		missingListWatch := persistentVolumeClaimKind()
+		missingListWatch.ListWatch = nil
+		missingStatus := persistentVolumeClaimKind()
+		missingStatus.Status = nil
+		for name, k := range map[string]kubeclient.Kind{
+			"duplicate name":     duplicateName,
+			"duplicate type":     duplicateType,
+			"missing name":       missingName,
+			"missing object":     missingObject,
+			"missing list watch": missingListWatch,
+			"missing status":     missingStatus,
+		} {
+			t.Run(name, func(t *testing.T) {
+				require.Error(t, r.Register(k))
+			})
+		}
+	})
+}
+
+func TestKind_MetadataOf(t *testing.T) {
+	k, ok := kubeclient.NewDefaultKindRegistry().Lookup("Service")
+	require.True(t, ok)
+	service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nodejs"}}
+	require.Equal(t, &service.ObjectMeta, k.MetadataOf(service))
+
+	k.Metadata = func(obj runtime.Object) interface{} {
+		return obj.(*corev1.Service).Name
+	}
+	require.Equal(t, "nodejs", k.MetadataOf(service))
+}
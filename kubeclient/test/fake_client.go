@@ -1,16 +1,170 @@
 package test
 
 import (
+	"encoding/json"
+	"strconv"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	deploymentconfigv1 "github.com/openshift/api/apps/v1"
+	buildv1 "github.com/openshift/api/build/v1"
+	imagev1 "github.com/openshift/api/image/v1"
+	routev1 "github.com/openshift/api/route/v1"
 	ocfakeappsclient "github.com/openshift/client-go/apps/clientset/versioned/fake"
+	ocfakebuildclient "github.com/openshift/client-go/build/clientset/versioned/fake"
+	ocfakeimageclient "github.com/openshift/client-go/image/clientset/versioned/fake"
 	ocfakerouteclient "github.com/openshift/client-go/route/clientset/versioned/fake"
+	errs "github.com/pkg/errors"
 	"github.com/redhat-developer/app-service/kubeclient"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
-func FakeKubeClient() *kubeclient.KubeClient {
+// FakeKubeClient returns a KubeClient backed by fake clientsets. The given
+// objects are seeded into the clientset that serves their type. Objects of the
+// default kinds are seeded into the dynamic client as well, which serves all
+// kinds like the API server does, but the fake clientsets do not share any
+// changes. Unlike the fake dynamic client of client-go, the dynamic client
+// applies JSON merge patches and rejects patches of a stale resource version.
+// Access reviews allow everything.
+func FakeKubeClient(objects ...runtime.Object) *kubeclient.KubeClient {
+	kinds := kubeclient.NewDefaultKindRegistry()
+	var coreObjects, appsObjects, routeObjects, buildObjects, imageObjects, dynamicObjects []runtime.Object
+	for _, obj := range objects {
+		if _, ok := obj.(*unstructured.Unstructured); !ok {
+			if u, ok := toUnstructured(kinds, obj); ok {
+				dynamicObjects = append(dynamicObjects, u)
+			}
+		}
+		switch obj.(type) {
+		case *deploymentconfigv1.DeploymentConfig:
+			appsObjects = append(appsObjects, obj)
+		case *routev1.Route:
+			routeObjects = append(routeObjects, obj)
+		case *buildv1.BuildConfig, *buildv1.Build:
+			buildObjects = append(buildObjects, obj)
+		case *imagev1.ImageStream:
+			imageObjects = append(imageObjects, obj)
+		case *unstructured.Unstructured:
+			dynamicObjects = append(dynamicObjects, obj)
+		default:
+			coreObjects = append(coreObjects, obj)
+		}
+	}
 	k := &kubeclient.KubeClient{}
-	k.CoreClient = fake.NewSimpleClientset()
-	k.OcRouteClient = ocfakerouteclient.NewSimpleClientset().RouteV1()
-	k.OcAppsClient = ocfakeappsclient.NewSimpleClientset().AppsV1()
+	coreClient := fake.NewSimpleClientset(coreObjects...)
+	coreClient.PrependReactor("create", "selfsubjectaccessreviews", AllowAccessReviews(true))
+	k.CoreClient = coreClient
+	k.OcRouteClient = ocfakerouteclient.NewSimpleClientset(routeObjects...).RouteV1()
+	k.OcAppsClient = ocfakeappsclient.NewSimpleClientset(appsObjects...).AppsV1()
+	k.OcBuildClient = ocfakebuildclient.NewSimpleClientset(buildObjects...).BuildV1()
+	k.OcImageClient = ocfakeimageclient.NewSimpleClientset(imageObjects...).ImageV1()
+	k.DynamicClient = fakeDynamicClient(dynamicObjects...)
 	return k
 }
+
+// Returns a fake dynamic client that is seeded with the objects and serves
+// them from its own tracker, so that the reactors which patch objects can use
+// the tracker. The client itself must not be used by its reactors.
+func fakeDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	scheme := runtime.NewScheme()
+	client := dynamicfake.NewSimpleDynamicClient(scheme)
+	tracker := k8stesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	for _, obj := range objects {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	client.PrependReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	client.PrependReactor("patch", "*", mergePatchReaction(tracker))
+	client.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		return err == nil, w, err
+	})
+	return client
+}
+
+// Returns a reactor that applies JSON merge patches to the objects of the
+// tracker. A patch that sets the resource version of the object only applies
+// if the object still has that version, like with the API server, and every
+// patch bumps the version.
+func mergePatchReaction(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.MergePatchType {
+			return false, nil, nil
+		}
+		gvr := action.GetResource()
+		obj, err := tracker.Get(gvr, action.GetNamespace(), patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return true, nil, errs.Errorf("unexpected object of type %T", obj)
+		}
+		var expected struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(patch.GetPatch(), &expected); err != nil {
+			return true, nil, apierrors.NewBadRequest(err.Error())
+		}
+		version := u.GetResourceVersion()
+		if expected.Metadata.ResourceVersion != "" && expected.Metadata.ResourceVersion != version {
+			return true, nil, apierrors.NewConflict(gvr.GroupResource(), patch.GetName(),
+				errs.Errorf("the object has been modified, version %s is not %s", version, expected.Metadata.ResourceVersion))
+		}
+		original, err := u.MarshalJSON()
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := jsonpatch.MergePatch(original, patch.GetPatch())
+		if err != nil {
+			return true, nil, apierrors.NewBadRequest(err.Error())
+		}
+		result := &unstructured.Unstructured{}
+		if err := result.UnmarshalJSON(patched); err != nil {
+			return true, nil, err
+		}
+		next, _ := strconv.Atoi(version)
+		result.SetResourceVersion(strconv.Itoa(next + 1))
+		if err := tracker.Update(gvr, result, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, result, nil
+	}
+}
+
+// Converts a typed object of a known kind into an unstructured object.
+func toUnstructured(kinds *kubeclient.KindRegistry, obj runtime.Object) (*unstructured.Unstructured, bool) {
+	kind, ok := kinds.KindOf(obj)
+	if !ok {
+		return nil, false
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		panic(err)
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(kind.Resource.GroupVersion().WithKind(kind.Name))
+	return u, true
+}
+
+// AllowAccessReviews returns a reactor for fake clientsets that answers self
+// subject access reviews with the given decision.
+func AllowAccessReviews(allowed bool) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+		review.Status.Allowed = allowed
+		return true, review, nil
+	}
+}
@@ -0,0 +1,214 @@
+package watcher
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	errs "github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// defaultBackoff is the initial delay between failed attempts to list or
+	// watch a source.
+	defaultBackoff = time.Second
+	// defaultMaxBackoff is the longest delay between failed attempts to list
+	// or watch a source.
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// sourceState is what a source remembers between its watches: the version to
+// resume from and the objects it has reported, so that a new listing can be
+// turned into the events that were missed.
+type sourceState struct {
+	resourceVersion string
+	objects         map[string]runtime.Object
+	backoff         time.Duration
+}
+
+// Lists and watches the source until the context is done. The watch is
+// resumed from the last seen resource version when it ends and the source is
+// listed again when that version is gone.
+func (w Watch) runSource(ctx context.Context, lw cache.ListerWatcher) {
+	state := &sourceState{objects: make(map[string]runtime.Object)}
+	for ctx.Err() == nil {
+		if state.resourceVersion == "" {
+			if err := w.listSource(ctx, lw, state); err != nil {
+				w.handleError(err)
+				w.wait(ctx, state)
+				continue
+			}
+		}
+
+		v, err := lw.Watch(metav1.ListOptions{ResourceVersion: state.resourceVersion})
+		if err != nil {
+			if isGone(err) {
+				state.resourceVersion = ""
+			}
+			w.handleError(errs.Wrap(err, "failed to watch"))
+			w.wait(ctx, state)
+			continue
+		}
+		started := time.Now()
+		if err := w.watchSource(ctx, v, state); err != nil {
+			w.handleError(err)
+			w.wait(ctx, state)
+			continue
+		}
+		// A watch that ends right away is retried like a failed one so that
+		// a misbehaving server does not keep the source spinning.
+		if time.Since(started) < w.Backoff {
+			w.wait(ctx, state)
+		}
+	}
+}
+
+// Lists the source and reports the differences to the objects that were
+// reported before.
+func (w Watch) listSource(ctx context.Context, lw cache.ListerWatcher, state *sourceState) error {
+	list, err := lw.List(metav1.ListOptions{})
+	if err != nil {
+		return errs.Wrap(err, "failed to list")
+	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return errs.Wrap(err, "failed to read the list metadata")
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return errs.Wrap(err, "failed to extract the list items")
+	}
+
+	objects := make(map[string]runtime.Object, len(items))
+	for _, item := range items {
+		key, err := objectKey(item)
+		if err != nil {
+			return err
+		}
+		objects[key] = item
+		eventType := watch.Added
+		if old, ok := state.objects[key]; ok {
+			if resourceVersion(old) == resourceVersion(item) {
+				continue
+			}
+			eventType = watch.Modified
+		}
+		if !w.send(ctx, watch.Event{Type: eventType, Object: item}) {
+			return nil
+		}
+	}
+	for key, old := range state.objects {
+		if _, ok := objects[key]; !ok {
+			if !w.send(ctx, watch.Event{Type: watch.Deleted, Object: old}) {
+				return nil
+			}
+		}
+	}
+	state.objects = objects
+	state.resourceVersion = listMeta.GetResourceVersion()
+	// Sources that do not version their lists would be listed over and over.
+	if state.resourceVersion == "" {
+		state.resourceVersion = "0"
+	}
+	return nil
+}
+
+// Forwards the events of a watch until it ends or the context is done. The
+// watch is stopped on return.
+func (w Watch) watchSource(ctx context.Context, v watch.Interface, state *sourceState) error {
+	defer v.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-v.ResultChan():
+			if !ok {
+				// The API server ends watches routinely.
+				return nil
+			}
+			if event.Type == watch.Error {
+				err := errorFromEvent(event)
+				if isGone(err) {
+					state.resourceVersion = ""
+				}
+				return err
+			}
+			key, err := objectKey(event.Object)
+			if err != nil {
+				return err
+			}
+			if event.Type == watch.Deleted {
+				delete(state.objects, key)
+			} else {
+				state.objects[key] = event.Object
+			}
+			if rv := resourceVersion(event.Object); rv != "" {
+				state.resourceVersion = rv
+			}
+			state.backoff = 0
+			if !w.send(ctx, event) {
+				return nil
+			}
+		}
+	}
+}
+
+// Waits before the next attempt, doubling the delay every time.
+func (w Watch) wait(ctx context.Context, state *sourceState) {
+	switch {
+	case state.backoff == 0:
+		state.backoff = w.Backoff
+	case state.backoff < w.MaxBackoff:
+		state.backoff *= 2
+		if state.backoff > w.MaxBackoff {
+			state.backoff = w.MaxBackoff
+		}
+	}
+	timer := time.NewTimer(state.backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// Converts the object of an error event into an error.
+func errorFromEvent(event watch.Event) error {
+	if status, ok := event.Object.(*metav1.Status); ok {
+		return errs.Wrap(&apierrors.StatusError{ErrStatus: *status}, "watch failed")
+	}
+	return errs.Errorf("watch failed with an unexpected object: %#v", event.Object)
+}
+
+// Checks whether the error tells that the requested resource version is too
+// old to watch from.
+func isGone(err error) bool {
+	err = errs.Cause(err)
+	if apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+		return true
+	}
+	status, ok := err.(apierrors.APIStatus)
+	return ok && status.Status().Code == http.StatusGone
+}
+
+func objectKey(obj runtime.Object) (string, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return "", errs.Wrap(err, "failed to get the key of the object")
+	}
+	return key, nil
+}
+
+func resourceVersion(obj runtime.Object) string {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return o.GetResourceVersion()
+}
@@ -0,0 +1,238 @@
+package watcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	errs "github.com/pkg/errors"
+	"github.com/redhat-developer/app-service/kubeclient"
+	"github.com/redhat-developer/app-service/kubeclient/test"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// scriptedListerWatcher hands out the prepared lists and watches in order and
+// records the options it was called with.
+type scriptedListerWatcher struct {
+	mutex        sync.Mutex
+	lists        []runtime.Object
+	watches      []*watch.FakeWatcher
+	listCalls    int
+	watchOptions []metav1.ListOptions
+}
+
+func (lw *scriptedListerWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	if lw.listCalls >= len(lw.lists) {
+		return nil, apierrors.NewServiceUnavailable("no more lists")
+	}
+	list := lw.lists[lw.listCalls]
+	lw.listCalls++
+	return list, nil
+}
+
+func (lw *scriptedListerWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	if len(lw.watchOptions) >= len(lw.watches) {
+		return nil, apierrors.NewServiceUnavailable("no more watches")
+	}
+	w := lw.watches[len(lw.watchOptions)]
+	lw.watchOptions = append(lw.watchOptions, options)
+	return w, nil
+}
+
+func (lw *scriptedListerWatcher) resourceVersions() []string {
+	lw.mutex.Lock()
+	defer lw.mutex.Unlock()
+	var versions []string
+	for _, options := range lw.watchOptions {
+		versions = append(versions, options.ResourceVersion)
+	}
+	return versions
+}
+
+func pod(name string, resourceVersion string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myproject", ResourceVersion: resourceVersion}}
+}
+
+func podList(resourceVersion string, pods ...*corev1.Pod) *corev1.PodList {
+	list := &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: resourceVersion}}
+	for _, p := range pods {
+		list.Items = append(list.Items, *p)
+	}
+	return list
+}
+
+func nextEvent(t *testing.T, events <-chan watch.Event) watch.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return watch.Event{}
+	}
+}
+
+func TestWatch_Source(t *testing.T) {
+	watches := []*watch.FakeWatcher{
+		watch.NewFakeWithChanSize(10, false),
+		watch.NewFakeWithChanSize(10, false),
+		watch.NewFakeWithChanSize(10, false),
+	}
+	lw := &scriptedListerWatcher{
+		lists: []runtime.Object{
+			podList("1", pod("nodejs", "1")),
+			podList("5", pod("perl", "5")),
+		},
+		watches: watches,
+	}
+	var errsMutex sync.Mutex
+	var watchErrs []error
+	newWatch := NewWatch("myproject", nil).
+		AddSource(lw).
+		SetErrorHandler(func(err error) {
+			errsMutex.Lock()
+			defer errsMutex.Unlock()
+			watchErrs = append(watchErrs, err)
+		}).
+		SetBackoff(time.Millisecond, 10*time.Millisecond).
+		SetFilters([]watch.EventType{watch.Added, watch.Modified, watch.Deleted})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newWatch.StartWatcher(ctx)
+	events := make(chan watch.Event, 10)
+	listenerDone := make(chan struct{})
+	go func() {
+		defer close(listenerDone)
+		newWatch.ListenWatcher(ctx, func(event watch.Event) {
+			events <- event
+		})
+	}()
+
+	// The initial listing is reported as added objects.
+	event := nextEvent(t, events)
+	require.Equal(t, watch.Added, event.Type)
+	require.Equal(t, "nodejs", event.Object.(*corev1.Pod).Name)
+
+	// A watch that ends is resumed from the last seen resource version
+	// without listing again.
+	watches[0].Modify(pod("nodejs", "2"))
+	event = nextEvent(t, events)
+	require.Equal(t, watch.Modified, event.Type)
+	watches[0].Stop()
+
+	// A resource version that is gone makes the source list again and
+	// report what changed in between.
+	watches[1].Error(&apierrors.NewGone("too old").ErrStatus)
+	received := map[watch.EventType]string{}
+	for i := 0; i < 2; i++ {
+		event = nextEvent(t, events)
+		received[event.Type] = event.Object.(*corev1.Pod).Name
+	}
+	require.Equal(t, map[watch.EventType]string{watch.Deleted: "nodejs", watch.Added: "perl"}, received)
+
+	// The new watch starts from the version of the new listing.
+	watches[2].Add(pod("ruby", "6"))
+	event = nextEvent(t, events)
+	require.Equal(t, "ruby", event.Object.(*corev1.Pod).Name)
+	require.Equal(t, []string{"1", "2", "5"}, lw.resourceVersions())
+
+	errsMutex.Lock()
+	require.Len(t, watchErrs, 1)
+	require.True(t, isGone(watchErrs[0]))
+	errsMutex.Unlock()
+
+	// The listener returns once the context is done.
+	cancel()
+	select {
+	case <-listenerDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("listener did not return after the context was done")
+	}
+}
+
+func TestWatch_SourceErrors(t *testing.T) {
+	// Neither lists nor watches are available.
+	lw := &scriptedListerWatcher{}
+	watchErrs := make(chan error, 10)
+	newWatch := NewWatch("myproject", nil).
+		AddSource(lw).
+		SetErrorHandler(func(err error) {
+			select {
+			case watchErrs <- err:
+			default:
+			}
+		}).
+		SetBackoff(time.Millisecond, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	newWatch.StartWatcher(ctx)
+
+	// Failed listings are reported and retried.
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-watchErrs:
+			require.True(t, apierrors.IsServiceUnavailable(errs.Cause(err)))
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for an error")
+		}
+	}
+
+	// The result stream is closed once the source has stopped.
+	cancel()
+	select {
+	case _, ok := <-newWatch.ResultStream:
+		require.False(t, ok)
+	case <-time.After(10 * time.Second):
+		t.Fatal("result stream was not closed")
+	}
+}
+
+func TestWatch_WatcherErrorEvents(t *testing.T) {
+	fake := watch.NewFakeWithChanSize(10, false)
+	watchErrs := make(chan error, 1)
+	newWatch := NewWatch("myproject", nil, fake).
+		SetErrorHandler(func(err error) {
+			watchErrs <- err
+		})
+	newWatch.StartWatcher(context.Background())
+
+	fake.Error(&apierrors.NewGone("too old").ErrStatus)
+	select {
+	case err := <-watchErrs:
+		require.True(t, isGone(err))
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the error")
+	}
+	newWatch.StopWatch()
+}
+
+func TestWatch_AddKinds(t *testing.T) {
+	k := test.FakeKubeClient(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", Namespace: "myproject"},
+	})
+	newWatch := NewWatch("myproject", k).
+		AddKinds(kubeclient.NewDefaultKindRegistry()).
+		SetFilters([]watch.EventType{watch.Added})
+	require.Len(t, newWatch.Sources, len(kubeclient.NewDefaultKindRegistry().Kinds()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	newWatch.StartWatcher(ctx)
+	var names []string
+	newWatch.ListenWatcher(ctx, func(event watch.Event) {
+		names = append(names, event.Object.(*appsv1.Deployment).Name)
+		cancel()
+	})
+	require.Equal(t, []string{"nodejs"}, names)
+}
@@ -1,8 +1,13 @@
 package watcher
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/redhat-developer/app-service/kubeclient"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
 )
 
 type Watch struct {
@@ -11,6 +16,17 @@ type Watch struct {
 	Namespace    string
 	Watchers     []watch.Interface
 	WatchFilters []watch.EventType
+
+	// Sources are watched like Watchers but are listed first and watched
+	// again whenever their watch ends.
+	Sources []cache.ListerWatcher
+	// OnError is called with the errors of the watchers and sources. Errors
+	// are dropped if it is nil.
+	OnError func(err error)
+	// Backoff is the delay between failed attempts to list or watch a
+	// source. It doubles with every failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
 }
 
 func NewWatch(namespace string, kc *kubeclient.KubeClient, watchers ...watch.Interface) *Watch {
@@ -19,6 +35,39 @@ func NewWatch(namespace string, kc *kubeclient.KubeClient, watchers ...watch.Int
 	w.ResultStream = make(chan watch.Event)
 	w.Namespace = namespace
 	w.Watchers = watchers
+	w.Backoff = defaultBackoff
+	w.MaxBackoff = defaultMaxBackoff
+	return w
+}
+
+// AddSource adds a source that is listed and watched once the watcher is
+// started.
+func (w *Watch) AddSource(lw cache.ListerWatcher) *Watch {
+	w.Sources = append(w.Sources, lw)
+	return w
+}
+
+// AddKinds adds a source for every kind of the registry that lists and
+// watches the namespace of the watch with its client.
+func (w *Watch) AddKinds(kinds *kubeclient.KindRegistry) *Watch {
+	for _, k := range kinds.Kinds() {
+		w.AddSource(k.ListWatch(w.Client, w.Namespace))
+	}
+	return w
+}
+
+// SetErrorHandler sets the function that is called with the errors of the
+// watchers and sources.
+func (w *Watch) SetErrorHandler(onError func(err error)) *Watch {
+	w.OnError = onError
+	return w
+}
+
+// SetBackoff sets the initial and maximum delay between failed attempts to
+// list or watch a source.
+func (w *Watch) SetBackoff(backoff time.Duration, maxBackoff time.Duration) *Watch {
+	w.Backoff = backoff
+	w.MaxBackoff = maxBackoff
 	return w
 }
 
@@ -27,32 +76,92 @@ func (w *Watch) SetFilters(filters []watch.EventType) *Watch {
 	return w
 }
 
-func (w Watch) StartWatcher() {
+// StartWatcher forwards the events of all watchers and sources to the result
+// stream until the context is done. Sources are watched again when their
+// watch ends, while a watcher ends for good. The result stream is closed once
+// all watchers and sources have ended.
+func (w Watch) StartWatcher(ctx context.Context) {
+	var wg sync.WaitGroup
 	for _, v := range w.Watchers {
-		go sendToChannel(v, w.ResultStream)
+		wg.Add(1)
+		go func(v watch.Interface) {
+			defer wg.Done()
+			w.sendToChannel(ctx, v)
+		}(v)
+	}
+	for _, lw := range w.Sources {
+		wg.Add(1)
+		go func(lw cache.ListerWatcher) {
+			defer wg.Done()
+			w.runSource(ctx, lw)
+		}(lw)
 	}
+	go func() {
+		wg.Wait()
+		close(w.ResultStream)
+	}()
 }
 
-func (w Watch) ListenWatcher(onEvent func(obj watch.Event)) {
+// ListenWatcher calls onEvent for every event that passes the filters. It
+// returns when the context is done or the result stream is closed.
+func (w Watch) ListenWatcher(ctx context.Context, onEvent func(obj watch.Event)) {
 	for {
-		obj := <-w.ResultStream
-		for _, v := range w.WatchFilters {
-			if v == obj.Type {
-				onEvent(obj)
+		select {
+		case <-ctx.Done():
+			return
+		case obj, ok := <-w.ResultStream:
+			if !ok {
+				return
+			}
+			for _, v := range w.WatchFilters {
+				if v == obj.Type {
+					onEvent(obj)
+				}
 			}
 		}
 	}
 }
 
+// StopWatch stops all watchers. Sources are stopped by the context that the
+// watcher was started with.
 func (w Watch) StopWatch() {
 	for _, v := range w.Watchers {
 		v.Stop()
 	}
 }
 
-func sendToChannel(w watch.Interface, ch chan watch.Event) {
+func (w Watch) sendToChannel(ctx context.Context, v watch.Interface) {
 	for {
-		v := <-w.ResultChan()
-		ch <- v
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-v.ResultChan():
+			if !ok {
+				return
+			}
+			if event.Type == watch.Error {
+				w.handleError(errorFromEvent(event))
+				continue
+			}
+			if !w.send(ctx, event) {
+				return
+			}
+		}
+	}
+}
+
+// Sends the event to the result stream unless the context is done first.
+func (w Watch) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case w.ResultStream <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w Watch) handleError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
 	}
 }
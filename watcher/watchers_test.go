@@ -1,7 +1,9 @@
 package watcher
 
 import (
-	"fmt"
+	"context"
+	"time"
+
 	"github.com/redhat-developer/app-service/kubeclient/test"
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
@@ -17,7 +19,7 @@ func TestNewWatch(t *testing.T) {
 	namespace := "myproject"
 	listOptions := metav1.ListOptions{}
 	onGetWatchError := func(err error) {
-		fmt.Errorf("Error is %+v", err)
+		t.Errorf("Error is %+v", err)
 	}
 	newWatch := NewWatch(namespace,
 		k,
@@ -25,11 +27,10 @@ func TestNewWatch(t *testing.T) {
 		k.GetPodWatcher(namespace, listOptions, onGetWatchError),
 		k.GetRouteWatcher(namespace, listOptions, onGetWatchError),
 		k.GetDeploymentWatcher(namespace, listOptions, onGetWatchError),
-		)
+	)
 
 	newWatch.SetFilters([]watch.EventType{watch.Added, watch.Modified})
 
-
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "demo-deployment",
@@ -71,8 +72,47 @@ func TestNewWatch(t *testing.T) {
 		t.Error(err)
 	}
 
-	newWatch.ListenWatcher(func(event watch.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	newWatch.StartWatcher(ctx)
+	defer newWatch.StopWatch()
+
+	received := false
+	newWatch.ListenWatcher(ctx, func(event watch.Event) {
 		t.Logf("New Event Received %+v", event)
+		received = true
+		cancel()
 	})
+	if !received {
+		t.Error("no event received")
+	}
 }
 
+func TestWatch_StopWatch(t *testing.T) {
+	k := test.FakeKubeClient()
+	namespace := "myproject"
+	onGetWatchError := func(err error) {
+		t.Errorf("Error is %+v", err)
+	}
+	newWatch := NewWatch(namespace,
+		k,
+		k.GetDeploymentWatcher(namespace, metav1.ListOptions{}, onGetWatchError),
+		k.GetPodWatcher(namespace, metav1.ListOptions{}, onGetWatchError),
+	)
+	newWatch.StartWatcher(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		newWatch.ListenWatcher(context.Background(), func(event watch.Event) {})
+		close(done)
+	}()
+
+	// Stopping all watchers closes the result stream, which ends the
+	// listener.
+	newWatch.StopWatch()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("listener did not return after the watchers were stopped")
+	}
+}